@@ -91,10 +91,39 @@ func (ie IndexExpression) String() string {
 	return out.String()
 }
 
+// struct that represents an assignment (<target> = <expression>), only
+// index expressions can be assigned to
+type AssignExpression struct {
+	Token  token.Token // = token
+	Target Expression
+	Value  Expression
+}
+
+func (ae AssignExpression) expressionNode()      {}
+func (ae AssignExpression) TokenLiteral() string { return ae.Token.Literal }
+func (ae AssignExpression) String() string {
+	var out bytes.Buffer
+
+	out.WriteString("(")
+	out.WriteString(ae.Target.String())
+	out.WriteString(" = ")
+	out.WriteString(ae.Value.String())
+	out.WriteString(")")
+	return out.String()
+}
+
 // struct that represents a HashMap
 type HashLiteral struct {
 	Token token.Token // the { token
-	Pairs map[Expression]Expression
+	// pairs are kept in source order in a slice since nodes like function
+	// and array literals can't be used as keys of a go map
+	Pairs []HashLiteralPair
+}
+
+// struct representing a single key: value pair of a hash literal
+type HashLiteralPair struct {
+	Key   Expression
+	Value Expression
 }
 
 func (hl HashLiteral) expressionNode()      {}
@@ -102,8 +131,8 @@ func (hl HashLiteral) TokenLiteral() string { return hl.Token.Literal }
 func (hl HashLiteral) String() string {
 	var out bytes.Buffer
 	pairs := []string{}
-	for key, value := range hl.Pairs {
-		pairs = append(pairs, key.String()+":"+value.String())
+	for _, pair := range hl.Pairs {
+		pairs = append(pairs, pair.Key.String()+":"+pair.Value.String())
 	}
 	out.WriteString("{")
 	out.WriteString(strings.Join(pairs, ", "))
@@ -136,6 +165,7 @@ type FunctionLiteral struct {
 	Token      token.Token  // fn token
 	Parameters []Identifier // list of parameters
 	Body       *BlockStatement
+	Name       string // name of the let binding the function is bound to (if any)
 }
 
 func (fl FunctionLiteral) expressionNode()      {}
@@ -194,6 +224,23 @@ func (ie IfExpression) String() string {
 	return out.String()
 }
 
+// struct that represents a do expression (do <block_statement>)
+// evaluates to the value of the last statement of the block
+type DoExpression struct {
+	Token token.Token // do token
+	Body  *BlockStatement
+}
+
+func (de DoExpression) expressionNode()      {}
+func (de DoExpression) TokenLiteral() string { return de.Token.Literal }
+func (de DoExpression) String() string {
+	var out bytes.Buffer
+	out.WriteString("do { ")
+	out.WriteString(de.Body.String())
+	out.WriteString(" }")
+	return out.String()
+}
+
 // struct that represents a string literal
 type StringLiteral struct {
 	Token token.Token // token.STRING
@@ -290,16 +337,21 @@ func (il IntegerLiteral) String() string       { return il.Token.Literal }
 
 // sturct representing a let statement (Statement)
 type LetStatement struct {
-	Token token.Token // token.Let token
-	Name  Identifier  // name of variable
-	Value Expression  // expression that produces the value
+	Token   token.Token   // token.Let token
+	Name    Identifier    // name of variable
+	Pattern *ArrayPattern // set instead of Name when destructuring an array
+	Value   Expression    // expression that produces the value
 }
 
 func (ls LetStatement) String() string {
 	var out bytes.Buffer
 
 	out.WriteString(ls.TokenLiteral() + " ")
-	out.WriteString(ls.Name.String())
+	if ls.Pattern != nil {
+		out.WriteString(ls.Pattern.String())
+	} else {
+		out.WriteString(ls.Name.String())
+	}
 	out.WriteString(" = ")
 	if ls.Value != nil {
 		out.WriteString(ls.Value.String())
@@ -313,6 +365,26 @@ func (ls LetStatement) String() string {
 func (ls LetStatement) statementNode()       {}
 func (ls LetStatement) TokenLiteral() string { return ls.Token.Literal }
 
+// struct representing the target of a destructuring let
+// (e.g let [a, b, ...rest] = arr)
+type ArrayPattern struct {
+	Token    token.Token // token.LBRACKET token
+	Elements []Identifier
+	Rest     *Identifier // nil when the pattern has no rest element
+}
+
+func (ap ArrayPattern) String() string {
+	elements := []string{}
+	for _, el := range ap.Elements {
+		elements = append(elements, el.String())
+	}
+	if ap.Rest != nil {
+		elements = append(elements, "..."+ap.Rest.String())
+	}
+
+	return "[" + strings.Join(elements, ", ") + "]"
+}
+
 // struct representing a return statement (Statement)
 type ReturnStatement struct {
 	Token       token.Token // token.RETURN token
@@ -336,6 +408,44 @@ func (rs ReturnStatement) String() string {
 func (rs ReturnStatement) statementNode()       {}
 func (rs ReturnStatement) TokenLiteral() string { return rs.Token.Literal }
 
+// struct that represents a while loop
+type WhileStatement struct {
+	Token     token.Token // token.WHILE token
+	Condition Expression
+	Body      *BlockStatement
+}
+
+func (ws WhileStatement) String() string {
+	var out bytes.Buffer
+	out.WriteString("while")
+	out.WriteString(ws.Condition.String())
+	out.WriteString(" ")
+	out.WriteString(ws.Body.String())
+	return out.String()
+}
+
+func (ws WhileStatement) statementNode()       {}
+func (ws WhileStatement) TokenLiteral() string { return ws.Token.Literal }
+
+// struct that represents a break statement (leaves the innermost loop)
+type BreakStatement struct {
+	Token token.Token // token.BREAK token
+}
+
+func (bs BreakStatement) String() string       { return bs.TokenLiteral() + ";" }
+func (bs BreakStatement) statementNode()       {}
+func (bs BreakStatement) TokenLiteral() string { return bs.Token.Literal }
+
+// struct that represents a continue statement (skips to the next
+// iteration of the innermost loop)
+type ContinueStatement struct {
+	Token token.Token // token.CONTINUE token
+}
+
+func (cs ContinueStatement) String() string       { return cs.TokenLiteral() + ";" }
+func (cs ContinueStatement) statementNode()       {}
+func (cs ContinueStatement) TokenLiteral() string { return cs.Token.Literal }
+
 // struct that represents Expression Statements (so it acts as a wrapper for lines
 // that contain only an expression)
 
@@ -16,7 +16,9 @@ func (ins Instructions) String() string {
 		def, err := Lookup(ins[i])
 
 		if err != nil {
+			// skip the unknown byte so the rest still gets listed
 			fmt.Fprintf(&out, "ERROR: %s\n", err)
+			i++
 			continue
 		}
 
@@ -42,6 +44,8 @@ func (ins Instructions) fmtInstruction(def *Definition, operands []int) string {
 		return def.Name
 	case 1:
 		return fmt.Sprintf("%s %d", def.Name, operands[0])
+	case 2:
+		return fmt.Sprintf("%s %d %d", def.Name, operands[0], operands[1])
 	}
 
 	return fmt.Sprintf("ERROR: unhandled operandCount for %s\n", def.Name)
@@ -67,6 +71,7 @@ const (
 	OpEqual
 	OpNotEqual
 	OpGreaterThan
+	OpGreaterEqual
 	// opcodes for predix expressions
 	OpMinus
 	OpBang
@@ -74,6 +79,9 @@ const (
 	// both tell to jump using 16-bit addresses
 	OpJumpNotTruthy
 	OpJump
+	OpJumpTruthy
+	// opcode that pushes a copy of the top element of the stack
+	OpDup
 	// opcode for pushing null into stack
 	OpNull
 	// opcodes for getting and setting variables at global level
@@ -100,6 +108,26 @@ const (
 	OpSetLocal
 	// opcode for getting a builtin object
 	OpGetBuiltin
+
+	// serialized bytecode refers to opcodes by number, so new ones go
+	// below this line to keep the numbers of the ones above stable
+
+	// opcode for a call in tail position of a function to itself, the vm
+	// reuses the current frame instead of pushing a new one
+	OpTailCall
+	// opcode for assigning to an element of an array or hash-map, expects
+	// the data-structure, the index and the value on the stack and leaves
+	// the value on the stack
+	OpSetIndex
+	// opcode for creating a closure, the first operand (2 bytes) is the
+	// index of the compiled function in the constant pool and the second
+	// (1 byte) the number of free variables on the stack it captures
+	OpClosure
+	// opcode for getting a free variable captured by the current closure
+	OpGetFree
+	// opcode for pushing the closure being executed, used by functions
+	// bound by a let inside another function to call themselves
+	OpCurrentClosure
 )
 
 type Definition struct {
@@ -109,33 +137,41 @@ type Definition struct {
 
 // struct that holds definitions for all of our opcodes
 var definitions = map[Opcode]*Definition{
-	OpConstant:      {"OpConstant", []int{2}},
-	OpAdd:           {"OpAdd", []int{}},
-	OpSub:           {"OpSub", []int{}},
-	OpMul:           {"OpMul", []int{}},
-	OpDiv:           {"OpDiv", []int{}},
-	OpPop:           {"OpPop", []int{}},
-	OpTrue:          {"OpTrue", []int{}},
-	OpFalse:         {"OpFalse", []int{}},
-	OpEqual:         {"OpEqual", []int{}},
-	OpNotEqual:      {"OpNotEqual", []int{}},
-	OpGreaterThan:   {"OpGreaterThan", []int{}},
-	OpMinus:         {"OpMinus", []int{}},
-	OpBang:          {"OpBang", []int{}},
-	OpJumpNotTruthy: {"OpJumpNotTruthy", []int{2}},
-	OpJump:          {"OpJump", []int{2}},
-	OpNull:          {"OpNull", []int{}},
-	OpGetGlobal:     {"OpGetGlobal", []int{2}},
-	OpSetGlobal:     {"OpGetGlobal", []int{2}},
-	OpArray:         {"OpArray", []int{2}},
-	OpHash:          {"OpHash", []int{2}},
-	OpIndex:         {"OpIndex", []int{}},
-	OpCall:          {"OpCall", []int{1}},
-	OpReturnValue:   {"OpReturnValue", []int{}},
-	OpReturn:        {"OpReturn", []int{}},
-	OpGetLocal:      {"OpGetLocal", []int{1}},
-	OpSetLocal:      {"OpSetLocal", []int{1}},
-	OpGetBuiltin:    {"OpGetBuiltin", []int{1}},
+	OpConstant:       {"OpConstant", []int{2}},
+	OpAdd:            {"OpAdd", []int{}},
+	OpSub:            {"OpSub", []int{}},
+	OpMul:            {"OpMul", []int{}},
+	OpDiv:            {"OpDiv", []int{}},
+	OpPop:            {"OpPop", []int{}},
+	OpTrue:           {"OpTrue", []int{}},
+	OpFalse:          {"OpFalse", []int{}},
+	OpEqual:          {"OpEqual", []int{}},
+	OpNotEqual:       {"OpNotEqual", []int{}},
+	OpGreaterThan:    {"OpGreaterThan", []int{}},
+	OpGreaterEqual:   {"OpGreaterEqual", []int{}},
+	OpMinus:          {"OpMinus", []int{}},
+	OpBang:           {"OpBang", []int{}},
+	OpJumpNotTruthy:  {"OpJumpNotTruthy", []int{2}},
+	OpJump:           {"OpJump", []int{2}},
+	OpJumpTruthy:     {"OpJumpTruthy", []int{2}},
+	OpDup:            {"OpDup", []int{}},
+	OpNull:           {"OpNull", []int{}},
+	OpGetGlobal:      {"OpGetGlobal", []int{2}},
+	OpSetGlobal:      {"OpSetGlobal", []int{2}},
+	OpArray:          {"OpArray", []int{2}},
+	OpHash:           {"OpHash", []int{2}},
+	OpIndex:          {"OpIndex", []int{}},
+	OpCall:           {"OpCall", []int{1}},
+	OpReturnValue:    {"OpReturnValue", []int{}},
+	OpReturn:         {"OpReturn", []int{}},
+	OpGetLocal:       {"OpGetLocal", []int{1}},
+	OpSetLocal:       {"OpSetLocal", []int{1}},
+	OpGetBuiltin:     {"OpGetBuiltin", []int{1}},
+	OpTailCall:       {"OpTailCall", []int{1}},
+	OpSetIndex:       {"OpSetIndex", []int{}},
+	OpClosure:        {"OpClosure", []int{2, 1}},
+	OpGetFree:        {"OpGetFree", []int{1}},
+	OpCurrentClosure: {"OpCurrentClosure", []int{}},
 }
 
 func Lookup(op byte) (*Definition, error) {
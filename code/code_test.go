@@ -15,6 +15,8 @@ func TestMake(t *testing.T) {
 		{OpConstant, []int{65534}, []byte{byte(OpConstant), 255, 254}},
 		{OpAdd, []int{}, []byte{byte(OpAdd)}},
 		{OpGetLocal, []int{255}, []byte{byte(OpGetLocal), 255}},
+		{OpSetIndex, []int{}, []byte{byte(OpSetIndex)}},
+		{OpClosure, []int{65534, 255}, []byte{byte(OpClosure), 255, 254, 255}},
 	}
 
 	for _, tc := range testCases {
@@ -36,6 +38,7 @@ func TestReadOperands(t *testing.T) {
 	}{
 		{OpConstant, []int{65535}, 2},
 		{OpGetLocal, []int{255}, 1},
+		{OpClosure, []int{65535, 255}, 3},
 	}
 
 	for _, tc := range testCases {
@@ -60,12 +63,16 @@ func TestInstructionString(t *testing.T) {
 		Make(OpGetLocal, 1),
 		Make(OpConstant, 2),
 		Make(OpConstant, 65535),
+		Make(OpSetIndex),
+		Make(OpClosure, 65535, 255),
 	}
 
 	expected := `0000 OpAdd
 0001 OpGetLocal 1
 0003 OpConstant 2
 0006 OpConstant 65535
+0009 OpSetIndex
+0010 OpClosure 65535 255
 `
 
 	concatted := Instructions{}
@@ -75,3 +82,41 @@ func TestInstructionString(t *testing.T) {
 
 	require.Equal(t, concatted.String(), expected)
 }
+
+func TestInstructionStringWithUnknownOpcode(t *testing.T) {
+	instructions := append(Instructions{255}, Make(OpSetGlobal, 1)...)
+
+	expected := `ERROR: opcode 255 not defined
+0001 OpSetGlobal 1
+`
+	require.Equal(t, expected, instructions.String())
+}
+
+func TestOpcodeNumbersAreStable(t *testing.T) {
+	// numbers of the opcodes as of the first serialized bytecode version
+	testCases := []struct {
+		op       Opcode
+		expected byte
+	}{
+		{OpConstant, 0},
+		{OpGreaterEqual, 11},
+		{OpJumpTruthy, 16},
+		{OpDup, 17},
+		{OpNull, 18},
+		{OpGetGlobal, 19},
+		{OpIndex, 23},
+		{OpCall, 24},
+		{OpReturnValue, 25},
+		{OpReturn, 26},
+		{OpGetLocal, 27},
+		{OpSetLocal, 28},
+		{OpGetBuiltin, 29},
+	}
+
+	for _, tc := range testCases {
+		require.Equal(t, tc.expected, byte(tc.op), definitions[tc.op].Name)
+	}
+	require.Greater(t, OpTailCall, OpGetBuiltin)
+	require.Greater(t, OpSetIndex, OpTailCall)
+	require.Greater(t, OpClosure, OpSetIndex)
+}
@@ -16,6 +16,18 @@ type CompilationScope struct {
 	instructions        code.Instructions
 	lastInstruction     EmittedInstruction
 	previousInstruction EmittedInstruction
+	// source line of every byte of instructions
+	lines []int
+	// name of the function being compiled, used to detect self tail calls
+	name string
+	// loops being compiled, the innermost one is last
+	loops []loopContext
+}
+
+// struct tracking the jump targets of a loop being compiled
+type loopContext struct {
+	start  int   // offset of the condition, where continue jumps to
+	breaks []int // offsets of the jumps of break statements, patched to the exit
 }
 
 type Compiler struct {
@@ -27,6 +39,46 @@ type Compiler struct {
 	scopeIndex int
 	// symbol table of the compiler
 	symbolTable *SymbolTable
+	// source line of the statement currently being compiled
+	line int
+	// offsets at which each top-level statement ends
+	statementEnds []int
+	// whether compile time optimizations (constant folding, function
+	// interning) are enabled
+	optimize bool
+	// constant indexes of the compiled functions added so far, used to
+	// intern identical functions when optimizing
+	functions map[functionKey]int
+	// whether unused let bindings are reported in Bytecode.Warnings
+	warnUnused bool
+	// let bindings defined so far, checked for usage by Bytecode()
+	bindings []binding
+	// whether the node about to be compiled is in tail position, meaning
+	// its value is returned from the current function as is
+	tail bool
+	// names of the functions bound by top-level lets of the program being
+	// compiled, they can be referenced before their let (e.g by mutually
+	// recursive functions)
+	hoisted map[string]bool
+	// hoisted functions that got their global at a reference before their
+	// let, the let keeps that global
+	forwardDefined map[string]bool
+}
+
+// struct that identifies a compiled function by everything the vm uses
+// to run it, functions with equal keys are interchangeable
+type functionKey struct {
+	instructions  string
+	numLocals     int
+	numParameters int
+	name          string
+}
+
+// struct representing a let binding and the symbol table it was defined in
+type binding struct {
+	table *SymbolTable
+	name  string
+	line  int
 }
 
 // struct representing an emitted instruction from the compiler
@@ -40,6 +92,11 @@ type EmittedInstruction struct {
 type Bytecode struct {
 	Instructions code.Instructions
 	Constants    []object.Object
+	Lines        []int // source line of each byte of Instructions
+	// offsets at which each top-level statement ends
+	StatementEnds []int
+	// let bindings that are never used (only when enabled with WarnUnused)
+	Warnings []string
 }
 
 func New() *Compiler {
@@ -59,10 +116,26 @@ func New() *Compiler {
 	}
 }
 
+// function for enabling compile time optimizations
+func (c *Compiler) Optimize() {
+	c.optimize = true
+}
+
+// function for enabling warnings about let bindings that are never used
+func (c *Compiler) WarnUnused() {
+	c.warnUnused = true
+}
+
 func (c *Compiler) currentInstructions() code.Instructions {
 	return c.scopes[c.scopeIndex].instructions
 }
 
+// function that returns the symbol table of the compiler, a host program
+// can Define names in it for the globals it injects into the vm
+func (c *Compiler) SymbolTable() *SymbolTable {
+	return c.symbolTable
+}
+
 func NewWithState(s *SymbolTable, constants []object.Object) *Compiler {
 	compiler := New()
 	compiler.symbolTable = s
@@ -71,15 +144,25 @@ func NewWithState(s *SymbolTable, constants []object.Object) *Compiler {
 }
 
 func (c *Compiler) Compile(node ast.Node) error {
+	c.trackLine(node)
+
+	// only the cases below that pass it on keep the tail position
+	tail := c.tail
+	c.tail = false
+
 	switch node := node.(type) {
 	case *ast.Program:
+		c.hoisted = hoistedFunctions(node)
+		c.forwardDefined = make(map[string]bool)
 		for _, s := range node.Statements {
 			err := c.Compile(s)
 			if err != nil {
 				return err
 			}
+			c.statementEnds = append(c.statementEnds, len(c.currentInstructions()))
 		}
 	case ast.ExpressionStatement:
+		c.tail = tail
 		err := c.Compile(node.Expression)
 		if err != nil {
 			return err
@@ -88,8 +171,19 @@ func (c *Compiler) Compile(node ast.Node) error {
 		// clean the stack
 		c.emit(code.OpPop)
 	case ast.InfixExpression:
-		// less-than operator (<) just reorder left and right branches
-		if node.Operator == "<" {
+		if c.optimize {
+			if value, ok := foldConstant(node); ok {
+				c.emit(code.OpConstant, c.addConstant(value))
+				return nil
+			}
+		}
+
+		if node.Operator == "&&" || node.Operator == "||" {
+			return c.compileLogicalExpression(node)
+		}
+
+		// less-than operators (<, <=) just reorder left and right branches
+		if node.Operator == "<" || node.Operator == "<=" {
 			err := c.Compile(node.Right)
 			if err != nil {
 				return err
@@ -100,7 +194,11 @@ func (c *Compiler) Compile(node ast.Node) error {
 				return err
 			}
 
-			c.emit(code.OpGreaterThan)
+			if node.Operator == "<" {
+				c.emit(code.OpGreaterThan)
+			} else {
+				c.emit(code.OpGreaterEqual)
+			}
 			return nil
 		}
 
@@ -125,6 +223,8 @@ func (c *Compiler) Compile(node ast.Node) error {
 			c.emit(code.OpMul)
 		case ">":
 			c.emit(code.OpGreaterThan)
+		case ">=":
+			c.emit(code.OpGreaterEqual)
 		case "==":
 			c.emit(code.OpEqual)
 		case "!=":
@@ -133,6 +233,15 @@ func (c *Compiler) Compile(node ast.Node) error {
 			return fmt.Errorf("unknown operator %s", node.Operator)
 		}
 	case ast.PrefixExpression:
+		// negating a literal of a non numeric type can never succeed
+		// so report it now, other operands are checked by the vm
+		if node.Operator == "-" {
+			if typ, ok := literalType(node.Right); ok &&
+				typ != object.INTEGER_OBJ && typ != object.FLOAT_OBJ {
+				return fmt.Errorf("unsupported type for negation: %s", typ.Display())
+			}
+		}
+
 		err := c.Compile(node.Right)
 		if err != nil {
 			return err
@@ -149,6 +258,9 @@ func (c *Compiler) Compile(node ast.Node) error {
 	case ast.StringLiteral:
 		str := &object.String{Value: node.Value}
 		c.emit(code.OpConstant, c.addConstant(str))
+	case ast.FloatLiteral:
+		float := &object.Float{Value: node.Value}
+		c.emit(code.OpConstant, c.addConstant(float))
 	case ast.IntegerLiteral:
 		// parse integerliteral and push it to constant pool
 		integer := &object.Integer{Value: node.Value}
@@ -165,29 +277,26 @@ func (c *Compiler) Compile(node ast.Node) error {
 
 		c.emit(code.OpArray, len(node.Elements))
 	case ast.HashLiteral:
-		keys := []ast.Expression{}
+		pairs := make([]ast.HashLiteralPair, len(node.Pairs))
+		copy(pairs, node.Pairs)
 
-		for k := range node.Pairs {
-			keys = append(keys, k)
-		}
-
-		sort.Slice(keys, func(i, j int) bool {
-			return keys[i].String() < keys[j].String()
+		sort.SliceStable(pairs, func(i, j int) bool {
+			return pairs[i].Key.String() < pairs[j].Key.String()
 		})
 
-		for _, k := range keys {
-			err := c.Compile(k)
+		for _, pair := range pairs {
+			err := c.Compile(pair.Key)
 			if err != nil {
 				return err
 			}
 
-			err = c.Compile(node.Pairs[k])
+			err = c.Compile(pair.Value)
 			if err != nil {
 				return err
 			}
 		}
 
-		c.emit(code.OpHash, len(keys)*2)
+		c.emit(code.OpHash, len(pairs)*2)
 
 	case ast.IfExpression:
 		err := c.Compile(node.Condition)
@@ -197,13 +306,18 @@ func (c *Compiler) Compile(node ast.Node) error {
 		// Emit an `OpJumpNotTruthy` with a bogus value
 		jumpNotTruthyPos := c.emit(code.OpJumpNotTruthy, 9999)
 
+		c.tail = tail
 		err = c.Compile(node.Consequence)
 		if err != nil {
 			return err
 		}
 
+		// the branch evaluates to its last expression, or to null when
+		// it ends with a statement that leaves nothing (e.g a let or a loop)
 		if c.lastInstructionIs(code.OpPop) {
 			c.removeLastPop()
+		} else if !c.lastInstructionIs(code.OpReturnValue) {
+			c.emit(code.OpNull)
 		}
 
 		// Emit an `OpJump` with a bogus value
@@ -216,6 +330,7 @@ func (c *Compiler) Compile(node ast.Node) error {
 		if node.Alternative == nil {
 			c.emit(code.OpNull)
 		} else {
+			c.tail = tail
 			err := c.Compile(node.Alternative)
 			if err != nil {
 				return err
@@ -223,13 +338,30 @@ func (c *Compiler) Compile(node ast.Node) error {
 
 			if c.lastInstructionIs(code.OpPop) {
 				c.removeLastPop()
+			} else if !c.lastInstructionIs(code.OpReturnValue) {
+				c.emit(code.OpNull)
 			}
 		}
 
 		afterAlternativePos := len(c.currentInstructions())
 		c.changeOperand(jumpPos, afterAlternativePos)
+	case ast.DoExpression:
+		start := len(c.currentInstructions())
+		c.tail = tail
+		err := c.Compile(node.Body)
+		if err != nil {
+			return err
+		}
+
+		// leave the value of the last expression on the stack
+		if len(c.currentInstructions()) > start && c.lastInstructionIs(code.OpPop) {
+			c.removeLastPop()
+		} else {
+			c.emit(code.OpNull)
+		}
 	case *ast.BlockStatement:
-		for _, stmt := range node.Statements {
+		for i, stmt := range node.Statements {
+			c.tail = tail && i == len(node.Statements)-1
 			err := c.Compile(stmt)
 
 			if err != nil {
@@ -243,12 +375,34 @@ func (c *Compiler) Compile(node ast.Node) error {
 			c.emit(code.OpFalse)
 		}
 	case ast.LetStatement:
+		if node.Pattern != nil {
+			return fmt.Errorf("destructuring let is not supported by the compiler")
+		}
+
+		// functions bound by let carry the binding name for stack traces
+		// and are defined before their body is compiled so they can recurse
+		line := c.line
+		var symbol Symbol
+		fn, isFunction := node.Value.(ast.FunctionLiteral)
+		if isFunction {
+			fn.Name = node.Name.Value
+			node.Value = fn
+			symbol = c.defineBinding(node.Name.Value)
+		}
+
 		err := c.Compile(node.Value)
 		if err != nil {
 			return err
 		}
 
-		symbol := c.symbolTable.Define(node.Name.Value)
+		if !isFunction {
+			symbol = c.defineBinding(node.Name.Value)
+		}
+		c.bindings = append(c.bindings, binding{
+			table: c.symbolTable,
+			name:  node.Name.Value,
+			line:  line,
+		})
 		// depending on the scope emit the corrent instruction
 		if symbol.Scope == GlobalScope {
 			c.emit(code.OpSetGlobal, symbol.Index)
@@ -258,8 +412,19 @@ func (c *Compiler) Compile(node ast.Node) error {
 
 	case ast.Identifier:
 		symbol, ok := c.symbolTable.Resolve(node.Value)
+		// a top-level function referenced before its let gets its global
+		// now, the vm reports calling it before the let runs
+		if !ok && c.hoisted[node.Value] {
+			c.globalSymbolTable().Define(node.Value)
+			c.forwardDefined[node.Value] = true
+			symbol, ok = c.symbolTable.Resolve(node.Value)
+		}
 		if !ok {
-			return fmt.Errorf("undefined variable %s", node.Value)
+			// identifiers made up by the parser when desugaring have no line
+			if node.Token.Line == 0 {
+				return fmt.Errorf("undefined variable %s", node.Value)
+			}
+			return fmt.Errorf("undefined variable %s on line %d", node.Value, node.Token.Line)
 		}
 
 		c.loadSymbol(symbol)
@@ -275,14 +440,45 @@ func (c *Compiler) Compile(node ast.Node) error {
 		}
 
 		c.emit(code.OpIndex)
+	case ast.AssignExpression:
+		target, ok := node.Target.(ast.IndexExpression)
+		if !ok {
+			return fmt.Errorf("invalid assignment target: %s", node.Target.String())
+		}
+
+		err := c.Compile(target.Left)
+		if err != nil {
+			return err
+		}
+
+		err = c.Compile(target.Index)
+		if err != nil {
+			return err
+		}
+
+		err = c.Compile(node.Value)
+		if err != nil {
+			return err
+		}
+
+		c.emit(code.OpSetIndex)
 	case ast.FunctionLiteral:
 		c.enterScope()
+		c.scopes[c.scopeIndex].name = node.Name
+
+		// a function bound by a let inside another function calls itself
+		// through the closure being executed, its local isn't set yet when
+		// the closure captures its free variables
+		if symbol, ok := c.symbolTable.Outer.store[node.Name]; ok && symbol.Scope == LocalScope {
+			c.symbolTable.DefineFunctionName(node.Name)
+		}
 
 		// treat call arguments as local bindings
 		for _, arg := range node.Parameters {
 			c.symbolTable.Define(arg.Value)
 		}
 
+		c.tail = true
 		err := c.Compile(node.Body)
 		if err != nil {
 			return err
@@ -298,6 +494,7 @@ func (c *Compiler) Compile(node ast.Node) error {
 			c.emit(code.OpReturn)
 		}
 
+		freeSymbols := c.symbolTable.FreeSymbols
 		numLocals := c.symbolTable.numDefinitions
 		instructions := c.leaveScope()
 
@@ -305,9 +502,36 @@ func (c *Compiler) Compile(node ast.Node) error {
 			Instructions:  instructions,
 			NumLocals:     numLocals,
 			NumParameters: len(node.Parameters),
+			Name:          node.Name,
 		}
-		c.emit(code.OpConstant, c.addConstant(compiledFn))
+		fnIndex := c.addFunction(compiledFn)
+		if len(freeSymbols) == 0 {
+			c.emit(code.OpConstant, fnIndex)
+			break
+		}
+
+		// the captured values are pushed for OpClosure to take them
+		for _, s := range freeSymbols {
+			c.loadSymbol(s)
+		}
+		c.emit(code.OpClosure, fnIndex, len(freeSymbols))
+	case ast.WhileStatement:
+		return c.compileWhileStatement(node)
+	case ast.BreakStatement:
+		loop := c.currentLoop()
+		if loop == nil {
+			return fmt.Errorf("break outside of loop")
+		}
+		// Emit an `OpJump` with a bogus value, patched once the loop ends
+		loop.breaks = append(loop.breaks, c.emit(code.OpJump, 9999))
+	case ast.ContinueStatement:
+		loop := c.currentLoop()
+		if loop == nil {
+			return fmt.Errorf("continue outside of loop")
+		}
+		c.emit(code.OpJump, loop.start)
 	case ast.ReturnStatement:
+		c.tail = true
 		err := c.Compile(node.ReturnValue)
 		if err != nil {
 			return err
@@ -317,7 +541,7 @@ func (c *Compiler) Compile(node ast.Node) error {
 	case ast.CallExpression:
 		err := c.Compile(node.Function)
 		if err != nil {
-			return nil
+			return err
 		}
 
 		for _, arg := range node.Arguments {
@@ -327,12 +551,227 @@ func (c *Compiler) Compile(node ast.Node) error {
 			}
 		}
 
-		c.emit(code.OpCall, len(node.Arguments))
+		if tail && c.isSelfCall(node) {
+			c.emit(code.OpTailCall, len(node.Arguments))
+		} else {
+			c.emit(code.OpCall, len(node.Arguments))
+		}
+	}
+
+	return nil
+}
+
+// function for compiling while loops, the condition is checked at the start
+// of every iteration and the body jumps back to it
+func (c *Compiler) compileWhileStatement(node ast.WhileStatement) error {
+	scope := &c.scopes[c.scopeIndex]
+	scope.loops = append(scope.loops, loopContext{start: len(c.currentInstructions())})
+
+	err := c.Compile(node.Condition)
+	if err != nil {
+		return err
+	}
+	// Emit an `OpJumpNotTruthy` with a bogus value
+	exitJumpPos := c.emit(code.OpJumpNotTruthy, 9999)
+
+	err = c.Compile(node.Body)
+	if err != nil {
+		return err
 	}
 
+	scope = &c.scopes[c.scopeIndex]
+	loop := scope.loops[len(scope.loops)-1]
+	c.emit(code.OpJump, loop.start)
+
+	exit := len(c.currentInstructions())
+	c.changeOperand(exitJumpPos, exit)
+	for _, pos := range loop.breaks {
+		c.changeOperand(pos, exit)
+	}
+
+	scope.loops = scope.loops[:len(scope.loops)-1]
 	return nil
 }
 
+// function that returns the innermost loop of the current scope
+// or nil when not compiling a loop
+func (c *Compiler) currentLoop() *loopContext {
+	loops := c.scopes[c.scopeIndex].loops
+	if len(loops) == 0 {
+		return nil
+	}
+	return &loops[len(loops)-1]
+}
+
+// function that determines if call is a call of the function currently
+// being compiled to itself (through the global it was bound to)
+func (c *Compiler) isSelfCall(call ast.CallExpression) bool {
+	ident, ok := call.Function.(ast.Identifier)
+	if !ok || ident.Value != c.scopes[c.scopeIndex].name {
+		return false
+	}
+
+	symbol, ok := c.symbolTable.Resolve(ident.Value)
+	return ok && symbol.Scope == GlobalScope
+}
+
+// function for compiling && and || so the right operand is only evaluated
+// when needed, the left operand is duplicated so it stays on the stack as the
+// result when the jump is taken and popped otherwise
+func (c *Compiler) compileLogicalExpression(node ast.InfixExpression) error {
+	err := c.Compile(node.Left)
+	if err != nil {
+		return err
+	}
+
+	c.emit(code.OpDup)
+
+	jumpOp := code.OpJumpNotTruthy
+	if node.Operator == "||" {
+		jumpOp = code.OpJumpTruthy
+	}
+	// Emit the jump with a bogus value
+	jumpPos := c.emit(jumpOp, 9999)
+
+	c.emit(code.OpPop)
+	err = c.Compile(node.Right)
+	if err != nil {
+		return err
+	}
+
+	c.changeOperand(jumpPos, len(c.currentInstructions()))
+	return nil
+}
+
+// function for evaluating arithmetic on number literals at compile time
+// with object.Arithmetic, the same rules the vm applies at runtime. an
+// expression that fails (e.g division by zero) is left for the vm to report
+func foldConstant(node ast.Expression) (object.Object, bool) {
+	switch node := node.(type) {
+	case ast.IntegerLiteral:
+		return &object.Integer{Value: node.Value}, true
+	case ast.FloatLiteral:
+		return &object.Float{Value: node.Value}, true
+	case ast.PrefixExpression:
+		if node.Operator != "-" {
+			return nil, false
+		}
+		right, ok := foldConstant(node.Right)
+		if !ok {
+			return nil, false
+		}
+		switch right := right.(type) {
+		case *object.Integer:
+			return &object.Integer{Value: -right.Value}, true
+		case *object.Float:
+			return &object.Float{Value: -right.Value}, true
+		}
+	case ast.InfixExpression:
+		switch node.Operator {
+		case "+", "-", "*", "/":
+		default:
+			return nil, false
+		}
+		left, ok := foldConstant(node.Left)
+		if !ok {
+			return nil, false
+		}
+		right, ok := foldConstant(node.Right)
+		if !ok {
+			return nil, false
+		}
+
+		result, err := object.Arithmetic(node.Operator, left, right)
+		if err != nil {
+			return nil, false
+		}
+		return result, true
+	}
+
+	return nil, false
+}
+
+// function that returns the type of object a literal evaluates to
+func literalType(node ast.Expression) (object.ObjectType, bool) {
+	switch node.(type) {
+	case ast.IntegerLiteral:
+		return object.INTEGER_OBJ, true
+	case ast.FloatLiteral:
+		return object.FLOAT_OBJ, true
+	case ast.StringLiteral:
+		return object.STRING_OBJ, true
+	case ast.Boolean:
+		return object.BOOLEAN_OBJ, true
+	case ast.ArrayLiteral:
+		return object.ARRAY_OBJ, true
+	case ast.HashLiteral:
+		return object.HASH_OBJ, true
+	case ast.FunctionLiteral:
+		return object.COMPILED_FUNCTION_OBJECT, true
+	default:
+		return "", false
+	}
+}
+
+// function for keeping track of the source line of the statement
+// being compiled so emitted instructions can be mapped back to it
+func (c *Compiler) trackLine(node ast.Node) {
+	switch node := node.(type) {
+	case ast.ExpressionStatement:
+		c.line = node.Token.Line
+	case ast.LetStatement:
+		c.line = node.Token.Line
+	case ast.ReturnStatement:
+		c.line = node.Token.Line
+	case ast.WhileStatement:
+		c.line = node.Token.Line
+	case ast.BreakStatement:
+		c.line = node.Token.Line
+	case ast.ContinueStatement:
+		c.line = node.Token.Line
+	}
+}
+
+// function for defining the name bound by a let. a let inside a loop body
+// keeps the slot the name already has so `let i = i + 1` updates the
+// variable the loop condition reads, and so does the let of a function
+// that was referenced before it. any other let defines a new slot
+func (c *Compiler) defineBinding(name string) Symbol {
+	if len(c.scopes[c.scopeIndex].loops) > 0 {
+		return c.symbolTable.Redefine(name)
+	}
+	if c.symbolTable.Outer == nil && c.forwardDefined[name] {
+		delete(c.forwardDefined, name)
+		return c.symbolTable.Redefine(name)
+	}
+	return c.symbolTable.Define(name)
+}
+
+// function that returns the names of the functions bound by the top-level
+// lets of program
+func hoistedFunctions(program *ast.Program) map[string]bool {
+	hoisted := make(map[string]bool)
+	for _, s := range program.Statements {
+		let, ok := s.(ast.LetStatement)
+		if !ok || let.Pattern != nil {
+			continue
+		}
+		if _, ok := let.Value.(ast.FunctionLiteral); ok {
+			hoisted[let.Name.Value] = true
+		}
+	}
+	return hoisted
+}
+
+// function that returns the outermost symbol table, where globals are defined
+func (c *Compiler) globalSymbolTable() *SymbolTable {
+	table := c.symbolTable
+	for table.Outer != nil {
+		table = table.Outer
+	}
+	return table
+}
+
 // function for emmiting correct instruction based on Symbol scope
 func (c *Compiler) loadSymbol(s Symbol) {
 	switch s.Scope {
@@ -342,6 +781,10 @@ func (c *Compiler) loadSymbol(s Symbol) {
 		c.emit(code.OpGetLocal, s.Index)
 	case BuiltinScope:
 		c.emit(code.OpGetBuiltin, s.Index)
+	case FreeScope:
+		c.emit(code.OpGetFree, s.Index)
+	case FunctionScope:
+		c.emit(code.OpCurrentClosure)
 	}
 }
 
@@ -392,6 +835,7 @@ func (c *Compiler) removeLastPop() {
 	new := old[:last.Position]
 
 	c.scopes[c.scopeIndex].instructions = new
+	c.scopes[c.scopeIndex].lines = c.scopes[c.scopeIndex].lines[:last.Position]
 	c.scopes[c.scopeIndex].lastInstruction = previous
 }
 
@@ -421,6 +865,10 @@ func (c *Compiler) addInstruction(ins []byte) int {
 	// assign the instructions to current scope
 	c.scopes[c.scopeIndex].instructions = updatedInstructions
 
+	for range ins {
+		c.scopes[c.scopeIndex].lines = append(c.scopes[c.scopeIndex].lines, c.line)
+	}
+
 	return posNewInstruction
 }
 
@@ -431,11 +879,55 @@ func (c *Compiler) addConstant(obj object.Object) int {
 	return len(c.constants) - 1
 }
 
+// function for adding a compiled function to the constant pool, when
+// optimizing a function identical to one added before reuses its constant
+func (c *Compiler) addFunction(fn *object.CompiledFunction) int {
+	if !c.optimize {
+		return c.addConstant(fn)
+	}
+
+	key := functionKey{
+		instructions:  string(fn.Instructions),
+		numLocals:     fn.NumLocals,
+		numParameters: fn.NumParameters,
+		name:          fn.Name,
+	}
+	if index, ok := c.functions[key]; ok {
+		return index
+	}
+
+	if c.functions == nil {
+		c.functions = make(map[functionKey]int)
+	}
+	index := c.addConstant(fn)
+	c.functions[key] = index
+	return index
+}
+
 func (c *Compiler) Bytecode() *Bytecode {
 	return &Bytecode{
-		Instructions: c.currentInstructions(),
-		Constants:    c.constants,
+		Instructions:  c.currentInstructions(),
+		Constants:     c.constants,
+		Lines:         c.scopes[c.scopeIndex].lines,
+		StatementEnds: c.statementEnds,
+		Warnings:      c.unusedWarnings(),
+	}
+}
+
+// function that reports the let bindings that were never resolved
+func (c *Compiler) unusedWarnings() []string {
+	if !c.warnUnused {
+		return nil
+	}
+
+	warnings := []string{}
+	for _, b := range c.bindings {
+		if !b.table.IsResolved(b.name) {
+			warnings = append(warnings,
+				fmt.Sprintf("line %d: %s declared but never used", b.line, b.name))
+		}
 	}
+	return warnings
 }
 
 func (c *Compiler) enterScope() {
@@ -40,6 +40,10 @@ func testConstants(t *testing.T, expected []interface{}, actual []object.Object)
 		switch constant := constant.(type) {
 		case int:
 			testIntegerObject(t, int64(constant), actual[i])
+		case float64:
+			float, ok := actual[i].(*object.Float)
+			require.True(t, ok)
+			require.Equal(t, constant, float.Value)
 		case string:
 			testStringObject(t, constant, actual[i])
 		case []code.Instructions:
@@ -230,6 +234,92 @@ func TestIndexExpressions(t *testing.T) {
 	runCompilerTests(t, testCases)
 }
 
+func TestIndexAssignment(t *testing.T) {
+	testCases := []compilerTestCase{
+		{
+			input:             "let a = [1, 2, 3]; a[0] = 9; a[0]",
+			expectedConstants: []interface{}{1, 2, 3, 0, 9, 0},
+			expectedInstructions: []code.Instructions{
+				code.Make(code.OpConstant, 0),
+				code.Make(code.OpConstant, 1),
+				code.Make(code.OpConstant, 2),
+				code.Make(code.OpArray, 3),
+				code.Make(code.OpSetGlobal, 0),
+				code.Make(code.OpGetGlobal, 0),
+				code.Make(code.OpConstant, 3),
+				code.Make(code.OpConstant, 4),
+				code.Make(code.OpSetIndex),
+				code.Make(code.OpPop),
+				code.Make(code.OpGetGlobal, 0),
+				code.Make(code.OpConstant, 5),
+				code.Make(code.OpIndex),
+				code.Make(code.OpPop),
+			},
+		},
+		{
+			input:             `{}["k"] = 1`,
+			expectedConstants: []interface{}{"k", 1},
+			expectedInstructions: []code.Instructions{
+				code.Make(code.OpHash, 0),
+				code.Make(code.OpConstant, 0),
+				code.Make(code.OpConstant, 1),
+				code.Make(code.OpSetIndex),
+				code.Make(code.OpPop),
+			},
+		},
+	}
+
+	runCompilerTests(t, testCases)
+}
+
+func TestCallStatementsArePopped(t *testing.T) {
+	function := []code.Instructions{
+		code.Make(code.OpConstant, 0),
+		code.Make(code.OpReturnValue),
+	}
+
+	testCases := []compilerTestCase{
+		{
+			input:             "let foo = fn() { 1 }; foo();",
+			expectedConstants: []interface{}{1, function},
+			expectedInstructions: []code.Instructions{
+				code.Make(code.OpConstant, 1),
+				code.Make(code.OpSetGlobal, 0),
+				code.Make(code.OpGetGlobal, 0),
+				code.Make(code.OpCall, 0),
+				code.Make(code.OpPop),
+			},
+		},
+		{
+			input:             "let foo = fn() { 1 }; let x = foo();",
+			expectedConstants: []interface{}{1, function},
+			expectedInstructions: []code.Instructions{
+				code.Make(code.OpConstant, 1),
+				code.Make(code.OpSetGlobal, 0),
+				code.Make(code.OpGetGlobal, 0),
+				code.Make(code.OpCall, 0),
+				code.Make(code.OpSetGlobal, 1),
+			},
+		},
+		{
+			input:             "let foo = fn() { 1 }; foo(); foo();",
+			expectedConstants: []interface{}{1, function},
+			expectedInstructions: []code.Instructions{
+				code.Make(code.OpConstant, 1),
+				code.Make(code.OpSetGlobal, 0),
+				code.Make(code.OpGetGlobal, 0),
+				code.Make(code.OpCall, 0),
+				code.Make(code.OpPop),
+				code.Make(code.OpGetGlobal, 0),
+				code.Make(code.OpCall, 0),
+				code.Make(code.OpPop),
+			},
+		},
+	}
+
+	runCompilerTests(t, testCases)
+}
+
 func TestFunctionsWithoutReturnValue(t *testing.T) {
 	testCases := []compilerTestCase{
 		{
@@ -342,6 +432,98 @@ func TestCompilerScopes(t *testing.T) {
 	require.Equal(t, code.OpMul, previous.Opcode)
 }
 
+func TestFunctionNames(t *testing.T) {
+	program := parse(`
+	let add = fn(a, b) { a + b };
+	fn() { 1 };
+	`)
+
+	compiler := New()
+	err := compiler.Compile(program)
+	require.NoError(t, err)
+
+	constants := compiler.Bytecode().Constants
+
+	named, ok := constants[0].(*object.CompiledFunction)
+	require.True(t, ok)
+	require.Equal(t, "add", named.Name)
+
+	anonymous, ok := constants[2].(*object.CompiledFunction)
+	require.True(t, ok)
+	require.Equal(t, "", anonymous.Name)
+}
+
+func TestLineTable(t *testing.T) {
+	program := parse(`let a = 1;
+let b = 2;
+
+if (a > b) { a } else { b };`)
+
+	compiler := New()
+	err := compiler.Compile(program)
+	require.NoError(t, err)
+
+	byteCode := compiler.Bytecode()
+	require.Equal(t, len(byteCode.Instructions), len(byteCode.Lines))
+
+	expected := map[int]int{
+		0:  1, // OpConstant 0
+		3:  1, // OpSetGlobal 0
+		6:  2, // OpConstant 1
+		9:  2, // OpSetGlobal 1
+		12: 4, // OpGetGlobal 0
+	}
+	for offset, line := range expected {
+		require.Equal(t, line, byteCode.Lines[offset])
+	}
+	require.Equal(t, 4, byteCode.Lines[len(byteCode.Lines)-1])
+}
+
+func TestPrefixOperandValidation(t *testing.T) {
+	testCases := []struct {
+		input         string
+		expectedError string
+	}{
+		{`-"x"`, "unsupported type for negation: string"},
+		{`-true`, "unsupported type for negation: boolean"},
+		{`-[1]`, "unsupported type for negation: array"},
+		{`-x`, ""},
+		{`-5`, ""},
+		{`-1.5`, ""},
+		{`!5`, ""},
+		{`!"x"`, ""},
+	}
+
+	for _, tc := range testCases {
+		program := parse("let x = 1; " + tc.input)
+
+		compiler := New()
+		err := compiler.Compile(program)
+
+		if tc.expectedError == "" {
+			require.NoError(t, err)
+		} else {
+			require.EqualError(t, err, tc.expectedError)
+		}
+	}
+}
+
+func TestFloatPrefixExpressions(t *testing.T) {
+	testCases := []compilerTestCase{
+		{
+			input:             "-1.5",
+			expectedConstants: []interface{}{1.5},
+			expectedInstructions: []code.Instructions{
+				code.Make(code.OpConstant, 0),
+				code.Make(code.OpMinus),
+				code.Make(code.OpPop),
+			},
+		},
+	}
+
+	runCompilerTests(t, testCases)
+}
+
 func TestArrayLiterals(t *testing.T) {
 	testCases := []compilerTestCase{
 		{
@@ -514,6 +696,26 @@ func TestBooleanExpressions(t *testing.T) {
 				code.Make(code.OpPop),
 			},
 		},
+		{
+			input:             "1 >= 2",
+			expectedConstants: []interface{}{1, 2},
+			expectedInstructions: []code.Instructions{
+				code.Make(code.OpConstant, 0),
+				code.Make(code.OpConstant, 1),
+				code.Make(code.OpGreaterEqual),
+				code.Make(code.OpPop),
+			},
+		},
+		{
+			input:             "1 <= 2",
+			expectedConstants: []interface{}{2, 1},
+			expectedInstructions: []code.Instructions{
+				code.Make(code.OpConstant, 0),
+				code.Make(code.OpConstant, 1),
+				code.Make(code.OpGreaterEqual),
+				code.Make(code.OpPop),
+			},
+		},
 		{
 			input:             "1 == 2",
 			expectedConstants: []interface{}{1, 2},
@@ -639,6 +841,57 @@ func TestConditionals(t *testing.T) {
 				code.Make(code.OpPop),
 			},
 		},
+		{
+			// only the pop of the trailing expression is removed, the let
+			// before it leaves nothing to keep
+			input:             "if (true) { let x = 1; x }",
+			expectedConstants: []interface{}{1},
+			expectedInstructions: []code.Instructions{
+				// 0000
+				code.Make(code.OpTrue),
+				// 0001
+				code.Make(code.OpJumpNotTruthy, 16),
+				// 0004
+				code.Make(code.OpConstant, 0),
+				// 0007
+				code.Make(code.OpSetGlobal, 0),
+				// 0010
+				code.Make(code.OpGetGlobal, 0),
+				// 0013
+				code.Make(code.OpJump, 17),
+				// 0016
+				code.Make(code.OpNull),
+				// 0017
+				code.Make(code.OpPop),
+			},
+		},
+		{
+			// a block ending with a let evaluates to null
+			input:             "if (true) { 1; let x = 2; }",
+			expectedConstants: []interface{}{1, 2},
+			expectedInstructions: []code.Instructions{
+				// 0000
+				code.Make(code.OpTrue),
+				// 0001
+				code.Make(code.OpJumpNotTruthy, 18),
+				// 0004
+				code.Make(code.OpConstant, 0),
+				// 0007
+				code.Make(code.OpPop),
+				// 0008
+				code.Make(code.OpConstant, 1),
+				// 0011
+				code.Make(code.OpSetGlobal, 0),
+				// 0014
+				code.Make(code.OpNull),
+				// 0015
+				code.Make(code.OpJump, 19),
+				// 0018
+				code.Make(code.OpNull),
+				// 0019
+				code.Make(code.OpPop),
+			},
+		},
 	}
 
 	runCompilerTests(t, tests)
@@ -868,3 +1121,627 @@ func TestGlobalStatements(t *testing.T) {
 
 	runCompilerTests(t, testCases)
 }
+
+func TestLogicalOperators(t *testing.T) {
+	testCases := []compilerTestCase{
+		{
+			input:             "true && false",
+			expectedConstants: []interface{}{},
+			expectedInstructions: []code.Instructions{
+				// 0000
+				code.Make(code.OpTrue),
+				// 0001
+				code.Make(code.OpDup),
+				// 0002
+				code.Make(code.OpJumpNotTruthy, 7),
+				// 0005
+				code.Make(code.OpPop),
+				// 0006
+				code.Make(code.OpFalse),
+				// 0007
+				code.Make(code.OpPop),
+			},
+		},
+		{
+			input:             "false || true",
+			expectedConstants: []interface{}{},
+			expectedInstructions: []code.Instructions{
+				// 0000
+				code.Make(code.OpFalse),
+				// 0001
+				code.Make(code.OpDup),
+				// 0002
+				code.Make(code.OpJumpTruthy, 7),
+				// 0005
+				code.Make(code.OpPop),
+				// 0006
+				code.Make(code.OpTrue),
+				// 0007
+				code.Make(code.OpPop),
+			},
+		},
+		{
+			// every OpDup is consumed by its jump, and the left operand
+			// is popped only on the path that evaluates the right one
+			input:             "let x = true && false || true;",
+			expectedConstants: []interface{}{},
+			expectedInstructions: []code.Instructions{
+				// 0000
+				code.Make(code.OpTrue),
+				// 0001
+				code.Make(code.OpDup),
+				// 0002
+				code.Make(code.OpJumpNotTruthy, 7),
+				// 0005
+				code.Make(code.OpPop),
+				// 0006
+				code.Make(code.OpFalse),
+				// 0007
+				code.Make(code.OpDup),
+				// 0008
+				code.Make(code.OpJumpTruthy, 13),
+				// 0011
+				code.Make(code.OpPop),
+				// 0012
+				code.Make(code.OpTrue),
+				// 0013
+				code.Make(code.OpSetGlobal, 0),
+			},
+		},
+	}
+
+	runCompilerTests(t, testCases)
+}
+
+func TestConstantFolding(t *testing.T) {
+	testCases := []compilerTestCase{
+		{
+			input:             "2 + 3",
+			expectedConstants: []interface{}{5},
+			expectedInstructions: []code.Instructions{
+				code.Make(code.OpConstant, 0),
+				code.Make(code.OpPop),
+			},
+		},
+		{
+			input:             "(1 + 2) * -3 - 4 / 2",
+			expectedConstants: []interface{}{-11},
+			expectedInstructions: []code.Instructions{
+				code.Make(code.OpConstant, 0),
+				code.Make(code.OpPop),
+			},
+		},
+		{
+			input:             "let a = 1; a + 2 * 3",
+			expectedConstants: []interface{}{1, 6},
+			expectedInstructions: []code.Instructions{
+				code.Make(code.OpConstant, 0),
+				code.Make(code.OpSetGlobal, 0),
+				code.Make(code.OpGetGlobal, 0),
+				code.Make(code.OpConstant, 1),
+				code.Make(code.OpAdd),
+				code.Make(code.OpPop),
+			},
+		},
+		{
+			// floats are folded with the promotion rules of the vm
+			input:             "1.5 * 2 + 1",
+			expectedConstants: []interface{}{4.0},
+			expectedInstructions: []code.Instructions{
+				code.Make(code.OpConstant, 0),
+				code.Make(code.OpPop),
+			},
+		},
+		{
+			input:             "7 / 2 - -0.5",
+			expectedConstants: []interface{}{3.5},
+			expectedInstructions: []code.Instructions{
+				code.Make(code.OpConstant, 0),
+				code.Make(code.OpPop),
+			},
+		},
+		{
+			input:             "1 / 0",
+			expectedConstants: []interface{}{1, 0},
+			expectedInstructions: []code.Instructions{
+				code.Make(code.OpConstant, 0),
+				code.Make(code.OpConstant, 1),
+				code.Make(code.OpDiv),
+				code.Make(code.OpPop),
+			},
+		},
+		{
+			input:             "1 < 2",
+			expectedConstants: []interface{}{2, 1},
+			expectedInstructions: []code.Instructions{
+				code.Make(code.OpConstant, 0),
+				code.Make(code.OpConstant, 1),
+				code.Make(code.OpGreaterThan),
+				code.Make(code.OpPop),
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		program := parse(tc.input)
+
+		compiler := New()
+		compiler.Optimize()
+		err := compiler.Compile(program)
+		require.NoError(t, err)
+
+		bytecode := compiler.Bytecode()
+		testInstructions(t, tc.expectedInstructions, bytecode.Instructions)
+		testConstants(t, tc.expectedConstants, bytecode.Constants)
+	}
+
+	// without Optimize the operation is left for the vm
+	compiler := New()
+	err := compiler.Compile(parse("2 + 3"))
+	require.NoError(t, err)
+	testInstructions(t, []code.Instructions{
+		code.Make(code.OpConstant, 0),
+		code.Make(code.OpConstant, 1),
+		code.Make(code.OpAdd),
+		code.Make(code.OpPop),
+	}, compiler.Bytecode().Instructions)
+}
+
+func TestTailCalls(t *testing.T) {
+	testCases := []compilerTestCase{
+		{
+			input: "let f = fn(x) { f(x) }; f(1)",
+			expectedConstants: []interface{}{
+				[]code.Instructions{
+					code.Make(code.OpGetGlobal, 0),
+					code.Make(code.OpGetLocal, 0),
+					code.Make(code.OpTailCall, 1),
+					code.Make(code.OpReturnValue),
+				},
+				1,
+			},
+			expectedInstructions: []code.Instructions{
+				code.Make(code.OpConstant, 0),
+				code.Make(code.OpSetGlobal, 0),
+				code.Make(code.OpGetGlobal, 0),
+				code.Make(code.OpConstant, 1),
+				code.Make(code.OpCall, 1),
+				code.Make(code.OpPop),
+			},
+		},
+		{
+			input: "let f = fn(x) { if (x) { return f(x); } else { f(x) } };",
+			expectedConstants: []interface{}{
+				[]code.Instructions{
+					// 0000
+					code.Make(code.OpGetLocal, 0),
+					// 0002
+					code.Make(code.OpJumpNotTruthy, 16),
+					// 0005
+					code.Make(code.OpGetGlobal, 0),
+					// 0008
+					code.Make(code.OpGetLocal, 0),
+					// 0010
+					code.Make(code.OpTailCall, 1),
+					// 0012
+					code.Make(code.OpReturnValue),
+					// 0013
+					code.Make(code.OpJump, 23),
+					// 0016
+					code.Make(code.OpGetGlobal, 0),
+					// 0019
+					code.Make(code.OpGetLocal, 0),
+					// 0021
+					code.Make(code.OpTailCall, 1),
+					// 0023
+					code.Make(code.OpReturnValue),
+				},
+			},
+			expectedInstructions: []code.Instructions{
+				code.Make(code.OpConstant, 0),
+				code.Make(code.OpSetGlobal, 0),
+			},
+		},
+		{
+			// neither call is in tail position
+			input: "let f = fn(x) { f(x) + 1; f(f(x)); 2 };",
+			expectedConstants: []interface{}{
+				1,
+				2,
+				[]code.Instructions{
+					code.Make(code.OpGetGlobal, 0),
+					code.Make(code.OpGetLocal, 0),
+					code.Make(code.OpCall, 1),
+					code.Make(code.OpConstant, 0),
+					code.Make(code.OpAdd),
+					code.Make(code.OpPop),
+					code.Make(code.OpGetGlobal, 0),
+					code.Make(code.OpGetGlobal, 0),
+					code.Make(code.OpGetLocal, 0),
+					code.Make(code.OpCall, 1),
+					code.Make(code.OpCall, 1),
+					code.Make(code.OpPop),
+					code.Make(code.OpConstant, 1),
+					code.Make(code.OpReturnValue),
+				},
+			},
+			expectedInstructions: []code.Instructions{
+				code.Make(code.OpConstant, 2),
+				code.Make(code.OpSetGlobal, 0),
+			},
+		},
+	}
+
+	runCompilerTests(t, testCases)
+}
+
+func TestUnusedWarnings(t *testing.T) {
+	testCases := []struct {
+		input            string
+		expectedWarnings []string
+	}{
+		{"let x = 1; let y = 2; y", []string{"line 1: x declared but never used"}},
+		{"let x = 1; x", []string{}},
+		{
+			"let f = fn(a) {\n let b = a;\n let c = 2;\n c\n};\nf(1)",
+			[]string{"line 2: b declared but never used"},
+		},
+		{
+			"let unused = fn() {\n 1\n};",
+			[]string{"line 1: unused declared but never used"},
+		},
+		{"let x = 1; let f = fn() { x }; f()", []string{}},
+	}
+
+	for _, tc := range testCases {
+		compiler := New()
+		compiler.WarnUnused()
+		err := compiler.Compile(parse(tc.input))
+		require.NoError(t, err)
+
+		require.Equal(t, tc.expectedWarnings, compiler.Bytecode().Warnings)
+	}
+
+	// warnings are opt-in
+	compiler := New()
+	err := compiler.Compile(parse("let x = 1;"))
+	require.NoError(t, err)
+	require.Nil(t, compiler.Bytecode().Warnings)
+}
+
+func TestHostDefinedSymbols(t *testing.T) {
+	compiler := New()
+	answer := compiler.SymbolTable().Define("answer")
+
+	err := compiler.Compile(parse("len([answer])"))
+	require.NoError(t, err)
+
+	testInstructions(t, []code.Instructions{
+		code.Make(code.OpGetBuiltin, 0),
+		code.Make(code.OpGetGlobal, answer.Index),
+		code.Make(code.OpArray, 1),
+		code.Make(code.OpCall, 1),
+		code.Make(code.OpPop),
+	}, compiler.Bytecode().Instructions)
+}
+
+func TestWhileLoops(t *testing.T) {
+	testCases := []compilerTestCase{
+		{
+			input:             "while (true) { break; continue; }; 1;",
+			expectedConstants: []interface{}{1},
+			expectedInstructions: []code.Instructions{
+				// 0000
+				code.Make(code.OpTrue),
+				// 0001
+				code.Make(code.OpJumpNotTruthy, 13),
+				// 0004
+				code.Make(code.OpJump, 13),
+				// 0007
+				code.Make(code.OpJump, 0),
+				// 0010
+				code.Make(code.OpJump, 0),
+				// 0013
+				code.Make(code.OpConstant, 0),
+				// 0016
+				code.Make(code.OpPop),
+			},
+		},
+		{
+			// break leaves the innermost loop only
+			input:             "while (true) { while (false) { break; }; break; }",
+			expectedConstants: []interface{}{},
+			expectedInstructions: []code.Instructions{
+				// 0000
+				code.Make(code.OpTrue),
+				// 0001
+				code.Make(code.OpJumpNotTruthy, 20),
+				// 0004
+				code.Make(code.OpFalse),
+				// 0005
+				code.Make(code.OpJumpNotTruthy, 14),
+				// 0008
+				code.Make(code.OpJump, 14),
+				// 0011
+				code.Make(code.OpJump, 4),
+				// 0014
+				code.Make(code.OpJump, 20),
+				// 0017
+				code.Make(code.OpJump, 0),
+			},
+		},
+	}
+
+	runCompilerTests(t, testCases)
+}
+
+func TestLoopControlOutsideLoop(t *testing.T) {
+	testCases := []struct {
+		input         string
+		expectedError string
+	}{
+		{"break;", "break outside of loop"},
+		{"continue;", "continue outside of loop"},
+		{"if (true) { break; }", "break outside of loop"},
+		// a function body doesn't see the loops around it
+		{"while (true) { fn() { break; }; }", "break outside of loop"},
+		{"while (true) { fn() { continue; }; }", "continue outside of loop"},
+		{"while (true) { if (true) { break; } else { continue; } }", ""},
+	}
+
+	for _, tc := range testCases {
+		program := parse(tc.input)
+
+		compiler := New()
+		err := compiler.Compile(program)
+
+		if tc.expectedError == "" {
+			require.NoError(t, err)
+		} else {
+			require.EqualError(t, err, tc.expectedError)
+		}
+	}
+}
+
+func TestDestructuringNotSupported(t *testing.T) {
+	program := parse("let [a, b] = [1, 2];")
+
+	compiler := New()
+	err := compiler.Compile(program)
+	require.EqualError(t, err, "destructuring let is not supported by the compiler")
+}
+
+func TestFunctionInterning(t *testing.T) {
+	testCases := []struct {
+		input             string
+		optimize          bool
+		expectedFunctions int
+	}{
+		{"fn(x) { x }; fn(x) { x }", true, 1},
+		{"fn(x) { x }; fn(x) { x }", false, 2},
+		{"[fn(x) { x }, fn(y) { y }, fn(x) { x + x }]", true, 2},
+		{"fn(x) { x }; fn(x, y) { x }", true, 2},
+		{"let f = fn(x) { x }; let g = fn(x) { x }", true, 2},
+		// the bodies refer to different constants of the pool
+		{"fn() { 1 }; fn() { 1 }", true, 2},
+	}
+
+	for _, tc := range testCases {
+		compiler := New()
+		if tc.optimize {
+			compiler.Optimize()
+		}
+		err := compiler.Compile(parse(tc.input))
+		require.NoError(t, err)
+
+		functions := 0
+		for _, constant := range compiler.Bytecode().Constants {
+			if _, ok := constant.(*object.CompiledFunction); ok {
+				functions++
+			}
+		}
+		require.Equal(t, tc.expectedFunctions, functions, tc.input)
+	}
+
+	compiler := New()
+	compiler.Optimize()
+	err := compiler.Compile(parse("fn(x) { x }; fn(x) { x }"))
+	require.NoError(t, err)
+	testInstructions(t, []code.Instructions{
+		code.Make(code.OpConstant, 0),
+		code.Make(code.OpPop),
+		code.Make(code.OpConstant, 0),
+		code.Make(code.OpPop),
+	}, compiler.Bytecode().Instructions)
+}
+
+func TestClosures(t *testing.T) {
+	testCases := []compilerTestCase{
+		{
+			input: `fn(a) { fn(b) { a + b } }`,
+			expectedConstants: []interface{}{
+				[]code.Instructions{
+					code.Make(code.OpGetFree, 0),
+					code.Make(code.OpGetLocal, 0),
+					code.Make(code.OpAdd),
+					code.Make(code.OpReturnValue),
+				},
+				[]code.Instructions{
+					code.Make(code.OpGetLocal, 0),
+					code.Make(code.OpClosure, 0, 1),
+					code.Make(code.OpReturnValue),
+				},
+			},
+			expectedInstructions: []code.Instructions{
+				code.Make(code.OpConstant, 1),
+				code.Make(code.OpPop),
+			},
+		},
+		{
+			// b is captured by the middle function so it can pass it on
+			input: `fn(a) { fn(b) { fn(c) { a + b + c } } }`,
+			expectedConstants: []interface{}{
+				[]code.Instructions{
+					code.Make(code.OpGetFree, 0),
+					code.Make(code.OpGetFree, 1),
+					code.Make(code.OpAdd),
+					code.Make(code.OpGetLocal, 0),
+					code.Make(code.OpAdd),
+					code.Make(code.OpReturnValue),
+				},
+				[]code.Instructions{
+					code.Make(code.OpGetFree, 0),
+					code.Make(code.OpGetLocal, 0),
+					code.Make(code.OpClosure, 0, 2),
+					code.Make(code.OpReturnValue),
+				},
+				[]code.Instructions{
+					code.Make(code.OpGetLocal, 0),
+					code.Make(code.OpClosure, 1, 1),
+					code.Make(code.OpReturnValue),
+				},
+			},
+			expectedInstructions: []code.Instructions{
+				code.Make(code.OpConstant, 2),
+				code.Make(code.OpPop),
+			},
+		},
+		{
+			// globals are read directly instead of being captured
+			input: `let g = 1; fn(a) { fn(b) { g + b } }`,
+			expectedConstants: []interface{}{
+				1,
+				[]code.Instructions{
+					code.Make(code.OpGetGlobal, 0),
+					code.Make(code.OpGetLocal, 0),
+					code.Make(code.OpAdd),
+					code.Make(code.OpReturnValue),
+				},
+				[]code.Instructions{
+					code.Make(code.OpConstant, 1),
+					code.Make(code.OpReturnValue),
+				},
+			},
+			expectedInstructions: []code.Instructions{
+				code.Make(code.OpConstant, 0),
+				code.Make(code.OpSetGlobal, 0),
+				code.Make(code.OpConstant, 2),
+				code.Make(code.OpPop),
+			},
+		},
+		{
+			// a local function calls itself through the current closure
+			input: `fn() { let countDown = fn(x) { countDown(x - 1) }; countDown(1) }`,
+			expectedConstants: []interface{}{
+				1,
+				[]code.Instructions{
+					code.Make(code.OpCurrentClosure),
+					code.Make(code.OpGetLocal, 0),
+					code.Make(code.OpConstant, 0),
+					code.Make(code.OpSub),
+					code.Make(code.OpCall, 1),
+					code.Make(code.OpReturnValue),
+				},
+				1,
+				[]code.Instructions{
+					code.Make(code.OpConstant, 1),
+					code.Make(code.OpSetLocal, 0),
+					code.Make(code.OpGetLocal, 0),
+					code.Make(code.OpConstant, 2),
+					code.Make(code.OpCall, 1),
+					code.Make(code.OpReturnValue),
+				},
+			},
+			expectedInstructions: []code.Instructions{
+				code.Make(code.OpConstant, 3),
+				code.Make(code.OpPop),
+			},
+		},
+	}
+
+	runCompilerTests(t, testCases)
+}
+
+func TestNestedFunctionLocals(t *testing.T) {
+	input := `
+	fn() {
+		let a = 1;
+		let inner = fn() {
+			let b = 2;
+			let c = 3;
+			let innermost = fn() { 4 };
+			let b = b + c;
+			b
+		};
+		a
+	}
+	`
+
+	compiler := New()
+	err := compiler.Compile(parse(input))
+	require.NoError(t, err)
+
+	// functions are added to the constants once their body is compiled,
+	// so the innermost one comes first
+	numLocals := []int{}
+	for _, constant := range compiler.Bytecode().Constants {
+		if fn, ok := constant.(*object.CompiledFunction); ok {
+			numLocals = append(numLocals, fn.NumLocals)
+		}
+	}
+	// innermost: none, inner: b, c, innermost and the redefined b,
+	// outer: a and inner
+	require.Equal(t, []int{0, 4, 2}, numLocals)
+}
+
+func TestBytecodeListing(t *testing.T) {
+	compiler := New()
+	err := compiler.Compile(parse(`fn(a) { a }(1); let greet = fn() { "hi" };`))
+	require.NoError(t, err)
+
+	expected := `Instructions:
+0000 OpConstant 0
+0003 OpConstant 1
+0006 OpCall 1
+0008 OpPop
+0009 OpConstant 3
+0012 OpSetGlobal 0
+Constants:
+0000 CompiledFunction[<anonymous>] parameters=1 locals=1
+  0000 OpGetLocal 0
+  0002 OpReturnValue
+0001 1
+0002 "hi"
+0003 CompiledFunction[greet] parameters=0 locals=0
+  0000 OpConstant 2
+  0003 OpReturnValue
+`
+	require.Equal(t, expected, compiler.Bytecode().String())
+}
+
+func TestUndefinedVariables(t *testing.T) {
+	testCases := []struct {
+		input         string
+		expectedError string
+	}{
+		{"x + 1", "undefined variable x on line 1"},
+		{"let a = 1;\nlet b = a + c;", "undefined variable c on line 2"},
+		{"let f = fn() {\n\n  y\n};", "undefined variable y on line 3"},
+		{"let a = 1;\na + 1", ""},
+	}
+
+	for _, tc := range testCases {
+		compiler := New()
+		err := compiler.Compile(parse(tc.input))
+		if tc.expectedError == "" {
+			require.NoError(t, err)
+		} else {
+			require.EqualError(t, err, tc.expectedError)
+		}
+	}
+
+	// nothing is emitted for the statement that failed
+	compiler := New()
+	require.Error(t, compiler.Compile(parse("x")))
+	require.Empty(t, compiler.Bytecode().Instructions)
+}
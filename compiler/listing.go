@@ -0,0 +1,42 @@
+package compiler
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+
+	"github.com/stevensopilidis/monkey/object"
+)
+
+// function that returns a human readable listing of the bytecode, the
+// main instructions followed by the constant pool where compiled
+// functions are listed with their own instructions
+func (b *Bytecode) String() string {
+	var out bytes.Buffer
+
+	out.WriteString("Instructions:\n")
+	out.WriteString(b.Instructions.String())
+
+	out.WriteString("Constants:\n")
+	for i, constant := range b.Constants {
+		fn, ok := constant.(*object.CompiledFunction)
+		if !ok {
+			fmt.Fprintf(&out, "%04d %s\n", i, object.ReplString(constant))
+			continue
+		}
+
+		name := fn.Name
+		if name == "" {
+			name = "<anonymous>"
+		}
+		fmt.Fprintf(&out, "%04d CompiledFunction[%s] parameters=%d locals=%d\n",
+			i, name, fn.NumParameters, fn.NumLocals)
+		for _, line := range strings.SplitAfter(fn.Instructions.String(), "\n") {
+			if line != "" {
+				out.WriteString("  " + line)
+			}
+		}
+	}
+
+	return out.String()
+}
@@ -0,0 +1,205 @@
+package compiler
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+
+	"github.com/stevensopilidis/monkey/code"
+	"github.com/stevensopilidis/monkey/object"
+)
+
+// magic bytes and version at the start of every serialized program
+const (
+	bytecodeMagic   = "MKBC"
+	bytecodeVersion = 1
+)
+
+// tags written before each constant of the constant pool
+const (
+	tagInteger byte = iota + 1
+	tagFloat
+	tagString
+	tagCompiledFunction
+)
+
+// function for encoding the bytecode into a byte stream so it can be
+// cached and rerun without compiling the source again
+func (b *Bytecode) Serialize() ([]byte, error) {
+	var buf bytes.Buffer
+
+	buf.WriteString(bytecodeMagic)
+	buf.WriteByte(bytecodeVersion)
+
+	writeBytes(&buf, b.Instructions)
+	writeInts(&buf, b.Lines)
+	writeInts(&buf, b.StatementEnds)
+
+	writeUint32(&buf, len(b.Constants))
+	for i, constant := range b.Constants {
+		switch constant := constant.(type) {
+		case *object.Integer:
+			buf.WriteByte(tagInteger)
+			writeUint64(&buf, uint64(constant.Value))
+		case *object.Float:
+			buf.WriteByte(tagFloat)
+			writeUint64(&buf, math.Float64bits(constant.Value))
+		case *object.String:
+			buf.WriteByte(tagString)
+			writeBytes(&buf, []byte(constant.Value))
+		case *object.CompiledFunction:
+			buf.WriteByte(tagCompiledFunction)
+			writeBytes(&buf, constant.Instructions)
+			writeUint32(&buf, constant.NumLocals)
+			writeUint32(&buf, constant.NumParameters)
+			writeBytes(&buf, []byte(constant.Name))
+		default:
+			return nil, fmt.Errorf("cannot serialize constant %d of type %s",
+				i, constant.Type())
+		}
+	}
+
+	return buf.Bytes(), nil
+}
+
+// function for decoding bytecode produced by Bytecode.Serialize
+func Deserialize(data []byte) (*Bytecode, error) {
+	r := &decoder{r: bytes.NewReader(data)}
+
+	magic := r.read(len(bytecodeMagic))
+	if r.err == nil && string(magic) != bytecodeMagic {
+		return nil, errors.New("not a serialized bytecode")
+	}
+	version := r.read(1)
+	if r.err == nil && version[0] != bytecodeVersion {
+		return nil, fmt.Errorf("unsupported bytecode version: %d", version[0])
+	}
+
+	bytecode := &Bytecode{
+		Instructions:  code.Instructions(r.bytes()),
+		Lines:         r.ints(),
+		StatementEnds: r.ints(),
+	}
+
+	count := r.uint32()
+	for i := 0; i < count && r.err == nil; i++ {
+		tag := r.read(1)
+		if r.err != nil {
+			break
+		}
+
+		switch tag[0] {
+		case tagInteger:
+			bytecode.Constants = append(bytecode.Constants,
+				&object.Integer{Value: int64(r.uint64())})
+		case tagFloat:
+			bytecode.Constants = append(bytecode.Constants,
+				&object.Float{Value: math.Float64frombits(r.uint64())})
+		case tagString:
+			bytecode.Constants = append(bytecode.Constants,
+				&object.String{Value: string(r.bytes())})
+		case tagCompiledFunction:
+			bytecode.Constants = append(bytecode.Constants, &object.CompiledFunction{
+				Instructions:  code.Instructions(r.bytes()),
+				NumLocals:     r.uint32(),
+				NumParameters: r.uint32(),
+				Name:          string(r.bytes()),
+			})
+		default:
+			return nil, fmt.Errorf("unknown constant tag %d", tag[0])
+		}
+	}
+
+	if r.err != nil {
+		return nil, fmt.Errorf("malformed bytecode: %w", r.err)
+	}
+	if r.r.Len() != 0 {
+		return nil, fmt.Errorf("malformed bytecode: %d trailing bytes", r.r.Len())
+	}
+
+	return bytecode, nil
+}
+
+func writeUint32(buf *bytes.Buffer, n int) {
+	var b [4]byte
+	binary.BigEndian.PutUint32(b[:], uint32(n))
+	buf.Write(b[:])
+}
+
+func writeUint64(buf *bytes.Buffer, n uint64) {
+	var b [8]byte
+	binary.BigEndian.PutUint64(b[:], n)
+	buf.Write(b[:])
+}
+
+// length prefixed bytes
+func writeBytes(buf *bytes.Buffer, b []byte) {
+	writeUint32(buf, len(b))
+	buf.Write(b)
+}
+
+// length prefixed ints
+func writeInts(buf *bytes.Buffer, ints []int) {
+	writeUint32(buf, len(ints))
+	for _, n := range ints {
+		writeUint32(buf, n)
+	}
+}
+
+// reader that remembers the first error so decoding can be
+// checked once at the end
+type decoder struct {
+	r   *bytes.Reader
+	err error
+}
+
+func (d *decoder) read(n int) []byte {
+	if d.err != nil {
+		return nil
+	}
+	if n > d.r.Len() {
+		d.err = io.ErrUnexpectedEOF
+		return nil
+	}
+
+	b := make([]byte, n)
+	d.r.Read(b)
+	return b
+}
+
+func (d *decoder) uint32() int {
+	b := d.read(4)
+	if b == nil {
+		return 0
+	}
+	return int(binary.BigEndian.Uint32(b))
+}
+
+func (d *decoder) uint64() uint64 {
+	b := d.read(8)
+	if b == nil {
+		return 0
+	}
+	return binary.BigEndian.Uint64(b)
+}
+
+func (d *decoder) bytes() []byte {
+	return d.read(d.uint32())
+}
+
+func (d *decoder) ints() []int {
+	count := d.uint32()
+	if count > d.r.Len()/4 {
+		d.err = io.ErrUnexpectedEOF
+		return nil
+	}
+
+	ints := make([]int, count)
+	for i := range ints {
+		ints[i] = d.uint32()
+	}
+	return ints
+}
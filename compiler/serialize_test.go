@@ -0,0 +1,117 @@
+package compiler
+
+import (
+	"testing"
+
+	"github.com/stevensopilidis/monkey/code"
+	"github.com/stevensopilidis/monkey/object"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSerializeRoundTrip(t *testing.T) {
+	testCases := []string{
+		"1 + 2",
+		"-1.5",
+		`"monkey"`,
+		`let greet = fn(name) { "hello " + name }; greet("monkey")`,
+		"let outer = fn() { fn(b) { let c = 1.5; b + c } }; outer()(2)",
+		"",
+	}
+
+	for _, input := range testCases {
+		compiler := New()
+		err := compiler.Compile(parse(input))
+		require.NoError(t, err)
+
+		bytecode := compiler.Bytecode()
+		data, err := bytecode.Serialize()
+		require.NoError(t, err)
+
+		decoded, err := Deserialize(data)
+		require.NoError(t, err)
+
+		require.Equal(t, bytecode.Instructions.String(), decoded.Instructions.String())
+		require.Equal(t, len(bytecode.Lines), len(decoded.Lines))
+		require.Equal(t, len(bytecode.StatementEnds), len(decoded.StatementEnds))
+		require.Equal(t, len(bytecode.Constants), len(decoded.Constants))
+		for i, constant := range bytecode.Constants {
+			require.Equal(t, constant, decoded.Constants[i])
+		}
+	}
+}
+
+func TestSerializeRoundTripOfNewerOpcodes(t *testing.T) {
+	testCases := []struct {
+		input  string
+		opcode string
+	}{
+		{"let a = [1, 2]; a[0] = 3; a", "OpSetIndex"},
+		{`let h = {}; h["k"] = 1;`, "OpSetIndex"},
+		{"let f = fn(n) { if (n == 0) { 0 } else { f(n - 1) } }; f(3)", "OpTailCall"},
+	}
+
+	for _, tc := range testCases {
+		compiler := New()
+		require.NoError(t, compiler.Compile(parse(tc.input)))
+
+		bytecode := compiler.Bytecode()
+		data, err := bytecode.Serialize()
+		require.NoError(t, err)
+
+		decoded, err := Deserialize(data)
+		require.NoError(t, err)
+		require.Equal(t, bytecode, decoded, tc.input)
+
+		listing := decoded.Instructions.String()
+		for _, constant := range decoded.Constants {
+			if fn, ok := constant.(*object.CompiledFunction); ok {
+				listing += fn.Instructions.String()
+			}
+		}
+		require.Contains(t, listing, tc.opcode, tc.input)
+	}
+}
+
+func TestSerializeConstantTypes(t *testing.T) {
+	bytecode := &Bytecode{
+		Instructions: code.Make(code.OpConstant, 0),
+		Lines:        []int{1, 1, 1},
+		Constants: []object.Object{
+			&object.Integer{Value: -42},
+			&object.Float{Value: 3.25},
+			&object.String{Value: "héllo"},
+			&object.CompiledFunction{
+				Instructions:  code.Make(code.OpReturn),
+				NumLocals:     2,
+				NumParameters: 1,
+				Name:          "f",
+			},
+		},
+		StatementEnds: []int{3},
+	}
+
+	data, err := bytecode.Serialize()
+	require.NoError(t, err)
+
+	decoded, err := Deserialize(data)
+	require.NoError(t, err)
+	require.Equal(t, bytecode, decoded)
+}
+
+func TestSerializeErrors(t *testing.T) {
+	bytecode := &Bytecode{Constants: []object.Object{object.TRUE}}
+	_, err := bytecode.Serialize()
+	require.EqualError(t, err, "cannot serialize constant 0 of type BOOLEAN")
+
+	_, err = Deserialize([]byte("nope!"))
+	require.EqualError(t, err, "not a serialized bytecode")
+
+	valid, err := (&Bytecode{Constants: []object.Object{&object.Integer{Value: 1}}}).Serialize()
+	require.NoError(t, err)
+
+	_, err = Deserialize(valid[:len(valid)-1])
+	require.EqualError(t, err, "malformed bytecode: unexpected EOF")
+
+	_, err = Deserialize(append(valid, 0))
+	require.EqualError(t, err, "malformed bytecode: 1 trailing bytes")
+}
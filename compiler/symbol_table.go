@@ -6,6 +6,11 @@ const (
 	GlobalScope  SymbolScope = "GLOBAL"
 	LocalScope   SymbolScope = "LOCAL"
 	BuiltinScope SymbolScope = "BUILTIN"
+	// locals of enclosing functions captured by a closure
+	FreeScope SymbolScope = "FREE"
+	// name a function bound by a let inside another function refers to
+	// itself with
+	FunctionScope SymbolScope = "FUNCTION"
 )
 
 type Symbol struct {
@@ -20,6 +25,11 @@ type SymbolTable struct {
 
 	store          map[string]Symbol
 	numDefinitions int
+	// names of the symbols of this table that have been resolved
+	resolved map[string]bool
+	// symbols of enclosing functions captured by this one, in the order
+	// their free indexes were handed out
+	FreeSymbols []Symbol
 }
 
 func NewSymbolTable() *SymbolTable {
@@ -57,20 +67,64 @@ func (st *SymbolTable) Define(name string) Symbol {
 	return symbol
 }
 
+// function for defining name again while keeping the slot it already has
+// in this table, so code compiled against the previous definition reads
+// the new value. names of outer tables, builtins, captured names and the
+// name of the function itself get a new slot
+func (st *SymbolTable) Redefine(name string) Symbol {
+	if symbol, ok := st.store[name]; ok && (symbol.Scope == GlobalScope || symbol.Scope == LocalScope) {
+		return symbol
+	}
+	return st.Define(name)
+}
+
+// function that determines if the symbol name of this table has been resolved
+func (st *SymbolTable) IsResolved(name string) bool {
+	return st.resolved[name]
+}
+
 func (s *SymbolTable) DefineBuiltin(index int, name string) Symbol {
 	symbol := Symbol{Name: name, Index: index, Scope: BuiltinScope}
 	s.store[name] = symbol
 	return symbol
 }
 
+// function for defining the name a function refers to itself with, it
+// takes no slot since the vm pushes the closure being executed for it
+func (st *SymbolTable) DefineFunctionName(name string) Symbol {
+	symbol := Symbol{Name: name, Index: 0, Scope: FunctionScope}
+	st.store[name] = symbol
+	return symbol
+}
+
+// function for capturing original, a symbol of an enclosing function,
+// as a free variable of this one
+func (st *SymbolTable) defineFree(original Symbol) Symbol {
+	st.FreeSymbols = append(st.FreeSymbols, original)
+
+	symbol := Symbol{Name: original.Name, Index: len(st.FreeSymbols) - 1, Scope: FreeScope}
+	st.store[original.Name] = symbol
+	return symbol
+}
+
 func (st *SymbolTable) Resolve(name string) (Symbol, bool) {
 	symbol, ok := st.store[name]
+	if ok {
+		if st.resolved == nil {
+			st.resolved = make(map[string]bool)
+		}
+		st.resolved[name] = true
+	}
 
 	// if its not on the local symbol table
 	// check recursively on the outer ones
 	if !ok && st.Outer != nil {
 		symbol, ok := st.Outer.Resolve(name)
-		return symbol, ok
+		if !ok || symbol.Scope == GlobalScope || symbol.Scope == BuiltinScope {
+			return symbol, ok
+		}
+		// a local of an enclosing function is captured by this one
+		return st.defineFree(symbol), true
 	}
 
 	return symbol, ok
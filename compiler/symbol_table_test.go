@@ -94,6 +94,85 @@ func TestResolveNestedLoacl(t *testing.T) {
 	}
 }
 
+func TestResolveFree(t *testing.T) {
+	global := NewSymbolTable()
+	global.Define("a")
+	global.DefineBuiltin(0, "len")
+
+	firstLocal := NewEnclosedSymbolTable(global)
+	firstLocal.Define("b")
+
+	secondLocal := NewEnclosedSymbolTable(firstLocal)
+	secondLocal.Define("c")
+
+	thirdLocal := NewEnclosedSymbolTable(secondLocal)
+	thirdLocal.Define("d")
+
+	testCases := []struct {
+		table               *SymbolTable
+		expectedSymbols     []Symbol
+		expectedFreeSymbols []Symbol
+	}{
+		{
+			secondLocal,
+			[]Symbol{
+				{Name: "a", Scope: GlobalScope, Index: 0},
+				{Name: "len", Scope: BuiltinScope, Index: 0},
+				{Name: "b", Scope: FreeScope, Index: 0},
+				{Name: "c", Scope: LocalScope, Index: 0},
+			},
+			[]Symbol{
+				{Name: "b", Scope: LocalScope, Index: 0},
+			},
+		},
+		{
+			// c is captured from the second table and b from its free
+			// variables, which the second table captured above
+			thirdLocal,
+			[]Symbol{
+				{Name: "c", Scope: FreeScope, Index: 0},
+				{Name: "b", Scope: FreeScope, Index: 1},
+				{Name: "d", Scope: LocalScope, Index: 0},
+			},
+			[]Symbol{
+				{Name: "c", Scope: LocalScope, Index: 0},
+				{Name: "b", Scope: FreeScope, Index: 0},
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		for _, expected := range tc.expectedSymbols {
+			result, ok := tc.table.Resolve(expected.Name)
+			require.True(t, ok)
+			require.Equal(t, expected, result)
+		}
+		require.Equal(t, tc.expectedFreeSymbols, tc.table.FreeSymbols)
+	}
+
+	_, ok := thirdLocal.Resolve("e")
+	require.False(t, ok)
+	require.Len(t, thirdLocal.FreeSymbols, 2)
+}
+
+func TestDefineFunctionName(t *testing.T) {
+	global := NewSymbolTable()
+	outer := NewEnclosedSymbolTable(global)
+	outer.Define("f")
+
+	fn := NewEnclosedSymbolTable(outer)
+	fn.DefineFunctionName("f")
+	fn.Define("x")
+
+	result, ok := fn.Resolve("f")
+	require.True(t, ok)
+	require.Equal(t, Symbol{Name: "f", Scope: FunctionScope, Index: 0}, result)
+	require.Empty(t, fn.FreeSymbols)
+
+	// a parameter or let of the same name takes its place
+	require.Equal(t, Symbol{Name: "f", Scope: LocalScope, Index: 1}, fn.Redefine("f"))
+}
+
 func TestDefine(t *testing.T) {
 	expected := map[string]Symbol{
 		"a": {Name: "a", Scope: GlobalScope, Index: 0},
@@ -151,3 +230,69 @@ func TestDefineResolveBuiltins(t *testing.T) {
 		}
 	}
 }
+
+func TestRedefine(t *testing.T) {
+	global := NewSymbolTable()
+	global.DefineBuiltin(0, "len")
+
+	// defining a name again gives it a new slot, code compiled before
+	// keeps reading the old one
+	a := global.Define("a")
+	global.Define("b")
+	require.Equal(t, Symbol{Name: "a", Scope: GlobalScope, Index: 2}, global.Define("a"))
+	resolved, ok := global.Resolve("a")
+	require.True(t, ok)
+	require.Equal(t, 2, resolved.Index)
+
+	// redefining keeps the slot
+	require.Equal(t, resolved, global.Redefine("a"))
+	require.NotEqual(t, a, global.Redefine("a"))
+	require.Equal(t, Symbol{Name: "new", Scope: GlobalScope, Index: 3}, global.Redefine("new"))
+
+	// builtins can be shadowed by a global of the same name
+	require.Equal(t, Symbol{Name: "len", Scope: GlobalScope, Index: 4}, global.Redefine("len"))
+
+	local := NewEnclosedSymbolTable(global)
+	c := local.Define("c")
+	require.Equal(t, Symbol{Name: "c", Scope: LocalScope, Index: 1}, local.Define("c"))
+	require.NotEqual(t, c, local.Define("c"))
+	require.Equal(t, Symbol{Name: "c", Scope: LocalScope, Index: 2}, local.Redefine("c"))
+	// shadowing an outer name defines a new local, with either of them
+	require.Equal(t, Symbol{Name: "a", Scope: LocalScope, Index: 3}, local.Define("a"))
+	require.Equal(t, Symbol{Name: "b", Scope: LocalScope, Index: 4}, local.Redefine("b"))
+
+	nested := NewEnclosedSymbolTable(local)
+	require.Equal(t, Symbol{Name: "c", Scope: LocalScope, Index: 0}, nested.Redefine("c"))
+	require.Equal(t, Symbol{Name: "c", Scope: LocalScope, Index: 0}, nested.Redefine("c"))
+	require.Equal(t, Symbol{Name: "c", Scope: LocalScope, Index: 1}, nested.Define("c"))
+	// the enclosing tables are left alone
+	resolved, _ = local.Resolve("c")
+	require.Equal(t, 2, resolved.Index)
+	resolved, _ = global.Resolve("b")
+	require.Equal(t, Symbol{Name: "b", Scope: GlobalScope, Index: 1}, resolved)
+
+	// a captured name is redefined as a local of its own
+	capturing := NewEnclosedSymbolTable(local)
+	resolved, _ = capturing.Resolve("c")
+	require.Equal(t, Symbol{Name: "c", Scope: FreeScope, Index: 0}, resolved)
+	require.Equal(t, Symbol{Name: "c", Scope: LocalScope, Index: 0}, capturing.Redefine("c"))
+}
+
+func TestIsResolved(t *testing.T) {
+	global := NewSymbolTable()
+	global.Define("a")
+	global.Define("b")
+
+	local := NewEnclosedSymbolTable(global)
+	local.Define("c")
+
+	_, ok := local.Resolve("a")
+	require.True(t, ok)
+	_, ok = local.Resolve("c")
+	require.True(t, ok)
+
+	require.True(t, global.IsResolved("a"))
+	require.False(t, global.IsResolved("b"))
+	require.True(t, local.IsResolved("c"))
+	require.False(t, local.IsResolved("a"))
+}
@@ -1,15 +1,165 @@
 package eval
 
 import (
+	"sort"
+
 	"github.com/stevensopilidis/monkey/object"
 )
 
-// map of Builtin functions
-var Builtins = map[string]*object.Builtin{
-	"len":   object.GetBuiltinByName("len"),
-	"puts":  object.GetBuiltinByName("puts"),
-	"first": object.GetBuiltinByName("first"),
-	"last":  object.GetBuiltinByName("last"),
-	"rest":  object.GetBuiltinByName("rest"),
-	"push":  object.GetBuiltinByName("push"),
+// map of Builtin functions, built from the builtins shared with the
+// compiler so both engines provide the same ones
+var Builtins = builtinsByName()
+
+func builtinsByName() map[string]*object.Builtin {
+	builtins := make(map[string]*object.Builtin, len(object.Builtins)+2)
+	for _, b := range object.Builtins {
+		builtins[b.Name] = b.Builtin
+	}
+	return builtins
+}
+
+// apply and sort need applyFunction which itself looks up Builtins, so
+// they're registered here to avoid an initialization cycle. they're the
+// only builtins of the evaluator alone since they call back into it
+func init() {
+	Builtins["apply"] = &object.Builtin{Fn: apply}
+	Builtins["sort"] = &object.Builtin{Fn: sortArray}
+}
+
+// builtin for calling fn with the elements of an array as its arguments
+// e.g apply(fn(a, b) { a + b }, [2, 3])
+func apply(args ...object.Object) object.Object {
+	if len(args) != 2 {
+		return newError("wrong number of arguments. got=%d, want=2", len(args))
+	}
+
+	switch args[0].(type) {
+	case object.Function, *object.Builtin:
+	default:
+		return newTypedError(object.TypeError, "first argument to `apply` must be function or builtin, got %s",
+			args[0].Type().Display())
+	}
+
+	arr, ok := args[1].(*object.Array)
+	if !ok {
+		return newTypedError(object.TypeError, "second argument to `apply` must be array, got %s",
+			args[1].Type().Display())
+	}
+
+	return applyFunction(args[0], arr.Elements)
+}
+
+// builtin that returns a sorted copy of an array, either of numbers or
+// strings in their natural order or of anything using a comparator that
+// returns a negative, zero or positive integer
+// e.g sort([3, 1, 2]) or sort(arr, fn(a, b) { b - a })
+func sortArray(args ...object.Object) object.Object {
+	if len(args) != 1 && len(args) != 2 {
+		return newError("wrong number of arguments. got=%d, want=1 or 2", len(args))
+	}
+
+	arr, ok := args[0].(*object.Array)
+	if !ok {
+		return newTypedError(object.TypeError, "first argument to `sort` must be array, got %s",
+			args[0].Type().Display())
+	}
+
+	elements := make([]object.Object, len(arr.Elements))
+	copy(elements, arr.Elements)
+
+	var less func(a, b object.Object) (bool, *object.Error)
+	if len(args) == 2 {
+		switch args[1].(type) {
+		case object.Function, *object.Builtin:
+		default:
+			return newTypedError(object.TypeError, "second argument to `sort` must be function or builtin, got %s",
+				args[1].Type().Display())
+		}
+		less = func(a, b object.Object) (bool, *object.Error) {
+			return compareWith(args[1], a, b)
+		}
+	} else {
+		if err := checkSortable(elements); err != nil {
+			return err
+		}
+		less = naturalLess
+	}
+
+	// sort can't be stopped midway so only the first error is kept and
+	// the remaining comparisons are skipped
+	var sortErr *object.Error
+	sort.SliceStable(elements, func(i, j int) bool {
+		if sortErr != nil {
+			return false
+		}
+		result, err := less(elements[i], elements[j])
+		if err != nil {
+			sortErr = err
+		}
+		return result
+	})
+	if sortErr != nil {
+		return sortErr
+	}
+
+	return &object.Array{Elements: elements}
+}
+
+// function that checks that elements are all numbers or all strings
+func checkSortable(elements []object.Object) *object.Error {
+	if len(elements) == 0 {
+		return nil
+	}
+	numeric := object.Numeric(elements[0])
+	for _, el := range elements {
+		if object.Numeric(el) && numeric {
+			continue
+		}
+		if _, ok := el.(object.String); ok && !numeric {
+			continue
+		}
+		return newTypedError(object.TypeError, "cannot sort %s with %s without a comparator",
+			elements[0].Type().Display(), el.Type().Display())
+	}
+	return nil
+}
+
+// function for ordering numbers and strings, their types are checked
+// beforehand by checkSortable
+func naturalLess(a, b object.Object) (bool, *object.Error) {
+	switch a := a.(type) {
+	case object.String:
+		return a.Value < b.(object.String).Value, nil
+	case *object.Integer:
+		if b, ok := b.(*object.Integer); ok {
+			return a.Value < b.Value, nil
+		}
+	}
+	x, ok := object.ToFloat(a)
+	if !ok {
+		return false, newTypedError(object.TypeError, "cannot sort %s", a.Type().Display())
+	}
+	y, ok := object.ToFloat(b)
+	if !ok {
+		return false, newTypedError(object.TypeError, "cannot sort %s", b.Type().Display())
+	}
+	return x < y, nil
+}
+
+// function for ordering a and b by calling the comparator fn with them
+func compareWith(fn, a, b object.Object) (bool, *object.Error) {
+	result := applyFunction(fn, []object.Object{a, b})
+	switch result := result.(type) {
+	case *object.Error:
+		return false, result
+	case *object.Integer:
+		return result.Value < 0, nil
+	case nil:
+		// a comparator with an empty body returns nothing
+		return false, newTypedError(object.TypeError, "comparator of `sort` must return integer, got %s",
+			object.NULL_OBJ.Display())
+	default:
+		return false, newTypedError(object.TypeError, "comparator of `sort` must return integer, got %s",
+			result.Type().Display())
+	}
 }
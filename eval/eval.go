@@ -2,17 +2,35 @@ package eval
 
 import (
 	"fmt"
+	"math"
+	"strings"
 
 	"github.com/stevensopilidis/monkey/ast"
 	"github.com/stevensopilidis/monkey/object"
 )
 
+// when enabled integer +, - and * report an error instead of
+// silently wrapping around on overflow
+var CheckOverflow = false
+
 var (
-	TRUE  = &object.Boolean{Value: true}
-	FALSE = &object.Boolean{Value: false}
-	NULL  = &object.Null{}
+	TRUE  = object.TRUE
+	FALSE = object.FALSE
+	NULL  = object.NULL
 )
 
+// function for evaluating a node without crashing the host, any panic
+// raised during evaluation is turned into an error object
+func EvalSafe(node ast.Node, env *object.Environment) (result object.Object) {
+	defer func() {
+		if r := recover(); r != nil {
+			result = newError("panic during evaluation: %v", r)
+		}
+	}()
+
+	return Eval(node, env)
+}
+
 func Eval(node ast.Node, env *object.Environment) object.Object {
 	switch node := node.(type) {
 	case *ast.Program:
@@ -34,11 +52,31 @@ func Eval(node ast.Node, env *object.Environment) object.Object {
 		}
 		return evalPrefixExpression(node.Operator, right)
 	case ast.InfixExpression:
+		if operands := plusChain(node); len(operands) > 2 {
+			return evalPlusChain(operands, env)
+		}
+
 		left := Eval(node.Left, env)
 		if isError(left) {
 			return left
 		}
 
+		// the right operand of && and || is only evaluated when needed
+		if node.Operator == "&&" && !object.IsTruthy(left) || node.Operator == "||" && object.IsTruthy(left) {
+			return left
+		}
+		if node.Operator == "&&" || node.Operator == "||" {
+			return Eval(node.Right, env)
+		}
+		// the right operand of ?? is only evaluated when the left is null,
+		// an empty block evaluates to nil which counts as null as well
+		if node.Operator == "??" {
+			if left != nil && left.Type() != object.NULL_OBJ {
+				return left
+			}
+			return Eval(node.Right, env)
+		}
+
 		right := Eval(node.Right, env)
 		if isError(right) {
 			return right
@@ -54,14 +92,29 @@ func Eval(node ast.Node, env *object.Environment) object.Object {
 			return val
 		}
 		return &object.ReturnValue{Value: val}
+	case ast.WhileStatement:
+		return evalWhileStatement(node, env)
+	case ast.BreakStatement:
+		return &object.Break{}
+	case ast.ContinueStatement:
+		return &object.Continue{}
 	case ast.LetStatement:
 		val := Eval(node.Value, env)
 		if isError(val) {
 			return val
 		}
+		if node.Pattern != nil {
+			return evalArrayPattern(node.Pattern, val, env)
+		}
 		env.Set(node.Name.Value, val)
 	case ast.IfExpression:
 		return evalIfExpression(node, env)
+	case ast.DoExpression:
+		result := evalBlockStatement(node.Body, env)
+		if result == nil {
+			return NULL
+		}
+		return result
 	case ast.FunctionLiteral:
 		params := node.Parameters
 		body := node.Body
@@ -96,6 +149,8 @@ func Eval(node ast.Node, env *object.Environment) object.Object {
 		}
 
 		return evalIndexExpression(left, index)
+	case ast.AssignExpression:
+		return evalAssignExpression(node, env)
 	case ast.HashLiteral:
 		return evalHashLiteral(node, env)
 	}
@@ -104,29 +159,28 @@ func Eval(node ast.Node, env *object.Environment) object.Object {
 
 // function for evaluating a HashLiteral
 func evalHashLiteral(node ast.HashLiteral, env *object.Environment) object.Object {
-	pairs := make(map[object.HashKey]object.HashPair)
+	hash := &object.Hash{Pairs: make(map[object.HashKey]object.HashPair)}
 
-	for keyNode, valueNode := range node.Pairs {
-		key := Eval(keyNode, env)
+	for _, pair := range node.Pairs {
+		key := Eval(pair.Key, env)
 		if isError(key) {
 			return key
 		}
 
 		hashKey, ok := key.(object.Hashable)
 		if !ok {
-			return newError("unusable as hash key: %s", key.Type())
+			return newTypedError(object.TypeError, "unusable as hash key: %s", key.Type().Display())
 		}
 
-		value := Eval(valueNode, env)
+		value := Eval(pair.Value, env)
 		if isError(value) {
 			return value
 		}
 
-		hashed := hashKey.HashKey()
-		pairs[hashed] = object.HashPair{Key: key, Value: value}
+		hash.Set(hashKey, value)
 	}
 
-	return &object.Hash{Pairs: pairs}
+	return hash
 }
 
 // function for evaluating IndexExpressions
@@ -136,21 +190,80 @@ func evalIndexExpression(left, index object.Object) object.Object {
 		return evalArrayIndexExpression(left, index)
 	case left.Type() == object.HASH_OBJ:
 		return evalHashIndexExpression(left, index)
+	case left.Type() == object.STRING_OBJ && index.Type() == object.INTEGER_OBJ:
+		char, ok := object.CharAt(left.(object.String).Value, index.(*object.Integer).Value)
+		if !ok {
+			return NULL
+		}
+		return object.String{Value: char}
 	default:
-		return newError("index operator not supported: %s", left.Type())
+		return newTypedError(object.TypeError, "index operator not supported: %s", left.Type().Display())
 	}
 }
 
+// function for evaluating an assignment to an element of an array or a
+// hash, the array or hash is mutated in place and the value is returned
+func evalAssignExpression(node ast.AssignExpression, env *object.Environment) object.Object {
+	target, ok := node.Target.(ast.IndexExpression)
+	if !ok {
+		return newTypedError(object.TypeError, "invalid assignment target: %s", node.Target.String())
+	}
+
+	left := Eval(target.Left, env)
+	if isError(left) {
+		return left
+	}
+
+	index := Eval(target.Index, env)
+	if isError(index) {
+		return index
+	}
+
+	value := Eval(node.Value, env)
+	if isError(value) {
+		return value
+	}
+
+	switch left := left.(type) {
+	case *object.Array:
+		if left.Frozen {
+			return newTypedError(object.TypeError, "cannot assign to frozen array")
+		}
+		idx, ok := index.(*object.Integer)
+		if !ok {
+			return newTypedError(object.TypeError, "array index must be integer, got %s", index.Type().Display())
+		}
+		if idx.Value < 0 || idx.Value >= int64(len(left.Elements)) {
+			return newTypedError(object.IndexError, "index out of range: %d (length %d)",
+				idx.Value, len(left.Elements))
+		}
+		left.Set(int(idx.Value), value)
+	case *object.Hash:
+		if left.Frozen {
+			return newTypedError(object.TypeError, "cannot assign to frozen hash")
+		}
+		key, ok := index.(object.Hashable)
+		if !ok {
+			return newTypedError(object.TypeError, "unusable as hash key: %s", index.Type().Display())
+		}
+		left.Set(key, value)
+	default:
+		return newTypedError(object.TypeError, "index assignment not supported: %s", left.Type().Display())
+	}
+
+	return value
+}
+
 // function for evaluating indexing at hashes
 func evalHashIndexExpression(hash, index object.Object) object.Object {
 	hashObject := hash.(*object.Hash)
 
 	key, ok := index.(object.Hashable)
 	if !ok {
-		return newError("unusable as hash key: %s", index.Type())
+		return newTypedError(object.TypeError, "unusable as hash key: %s", index.Type().Display())
 	}
 
-	pair, ok := hashObject.Pairs[key.HashKey()]
+	pair, ok := hashObject.Get(key)
 
 	if !ok {
 		return NULL
@@ -176,21 +289,31 @@ func evalArrayIndexExpression(array, index object.Object) object.Object {
 func applyFunction(fn object.Object, args []object.Object) object.Object {
 	switch fn := fn.(type) {
 	case object.Function:
+		if len(args) != len(fn.Parameters) {
+			return newTypedError(object.TypeError, "wrong number of arguments: want=%d, got=%d",
+				len(fn.Parameters), len(args))
+		}
 		extendedEnv := extendedFunctionEnv(fn, args)
 		evaluated := Eval(fn.Body, extendedEnv)
-		return unwrapReturnValue(evaluated)
+		return checkLoopControl(unwrapReturnValue(evaluated))
 	case *object.Builtin:
 		if result := fn.Fn(args...); result != nil {
 			return result
 		}
 
 		return NULL
+	case *object.CompiledFunction, *object.Closure:
+		// compiled functions can only be executed by the vm
+		return newTypedError(object.TypeError, "cannot call compiled function in interpreter mode")
 	default:
-		return newError("not a function: %s", fn.Type())
+		return newTypedError(object.TypeError, "not a function: %s", fn.Type().Display())
 	}
 }
 
-// function for created extended env for a function
+// function for created extended env for a function. it encloses the env
+// the function was defined in rather than a copy of it, so bindings made
+// there after the definition (e.g a function defined later that this one
+// calls) are visible when the function runs
 func extendedFunctionEnv(fn object.Function, args []object.Object) *object.Environment {
 	env := object.NewEnclosedEnvironment(fn.Env)
 
@@ -204,7 +327,7 @@ func extendedFunctionEnv(fn object.Function, args []object.Object) *object.Envir
 
 // function for unwrapping the return value from a function call
 func unwrapReturnValue(obj object.Object) object.Object {
-	if returnValue, ok := obj.(object.ReturnValue); ok {
+	if returnValue, ok := obj.(*object.ReturnValue); ok {
 		return returnValue.Value
 	}
 	return obj
@@ -237,7 +360,7 @@ func evalIdentifier(node ast.Identifier, env *object.Environment) object.Object
 		return Builtin
 	}
 
-	return newError("identifier not found: " + node.Value)
+	return newTypedError(object.NameError, "identifier not found: "+node.Value)
 }
 
 // function for checking if object is Error
@@ -254,6 +377,41 @@ func newError(format string, a ...interface{}) *object.Error {
 	return &object.Error{Message: fmt.Sprintf(format, a...)}
 }
 
+// function for creating a new Error message of a specific kind
+func newTypedError(kind object.ErrorKind, format string, a ...interface{}) *object.Error {
+	return &object.Error{Message: fmt.Sprintf(format, a...), Kind: kind}
+}
+
+// function for binding the elements of an array to the identifiers of a
+// destructuring let, without a rest element the lengths must match
+func evalArrayPattern(pattern *ast.ArrayPattern, val object.Object, env *object.Environment) object.Object {
+	arr, ok := val.(*object.Array)
+	if !ok {
+		return newTypedError(object.TypeError, "cannot destructure %s, expected array", val.Type().Display())
+	}
+
+	want, got := len(pattern.Elements), len(arr.Elements)
+	if pattern.Rest == nil && got != want {
+		return newTypedError(object.TypeError,
+			"wrong number of values to destructure: want=%d, got=%d", want, got)
+	}
+	if pattern.Rest != nil && got < want {
+		return newTypedError(object.TypeError,
+			"not enough values to destructure: want at least %d, got=%d", want, got)
+	}
+
+	for i, ident := range pattern.Elements {
+		env.Set(ident.Value, arr.Elements[i])
+	}
+	if pattern.Rest != nil {
+		rest := make([]object.Object, got-want)
+		copy(rest, arr.Elements[want:])
+		env.Set(pattern.Rest.Value, &object.Array{Elements: rest})
+	}
+
+	return nil
+}
+
 // function for evaluating if-else expressions
 func evalIfExpression(ie ast.IfExpression, env *object.Environment) object.Object {
 	condition := Eval(ie.Condition, env)
@@ -262,7 +420,7 @@ func evalIfExpression(ie ast.IfExpression, env *object.Environment) object.Objec
 		return condition
 	}
 
-	if isTruthy(condition) {
+	if object.IsTruthy(condition) {
 		return Eval(ie.Consequence, env)
 	} else if ie.Alternative != nil {
 		return Eval(ie.Alternative, env)
@@ -271,27 +429,59 @@ func evalIfExpression(ie ast.IfExpression, env *object.Environment) object.Objec
 	}
 }
 
-// function that evaluates if a condition is truthy (not false or null)
-func isTruthy(obj object.Object) bool {
-	if obj == NULL || obj == FALSE {
-		return false
-	}
-
-	return true
-}
-
 // function for evaluating a block statement
 func evalBlockStatement(block *ast.BlockStatement, env *object.Environment) object.Object {
 	var result object.Object
 	for _, statement := range block.Statements {
 		result = Eval(statement, env)
-		if result != nil && result.Type() == object.RETURN_VALUE_OBJ || result.Type() == object.ERROR_OBJ {
+		if result == nil {
+			continue
+		}
+
+		switch result.Type() {
+		case object.RETURN_VALUE_OBJ, object.ERROR_OBJ, object.BREAK_OBJ, object.CONTINUE_OBJ:
 			return result
 		}
 	}
 	return result
 }
 
+// function for evaluating while loops, break and continue objects coming
+// out of the body are consumed here
+func evalWhileStatement(ws ast.WhileStatement, env *object.Environment) object.Object {
+	for {
+		condition := Eval(ws.Condition, env)
+		if isError(condition) {
+			return condition
+		}
+		if !object.IsTruthy(condition) {
+			return NULL
+		}
+
+		result := Eval(ws.Body, env)
+		if result == nil {
+			continue
+		}
+
+		switch result.Type() {
+		case object.RETURN_VALUE_OBJ, object.ERROR_OBJ:
+			return result
+		case object.BREAK_OBJ:
+			return NULL
+		}
+	}
+}
+
+// function that turns a break or continue that escaped every loop into an error
+func checkLoopControl(obj object.Object) object.Object {
+	switch obj.(type) {
+	case *object.Break, *object.Continue:
+		return newError("%s outside of loop", obj.Inspect())
+	default:
+		return obj
+	}
+}
+
 // function for evaluating an infix expression
 func evalInfixExpression(operator string, left object.Object, right object.Object) object.Object {
 	_, okBoolLeft := left.(*object.Boolean)
@@ -304,73 +494,263 @@ func evalInfixExpression(operator string, left object.Object, right object.Objec
 		return nativeBoolToBooleanObject(left != right)
 	}
 
+	// `1 < 2 < 3` is parsed as `(1 < 2) < 3`, so hint at the likely mistake
+	if okBoolLeft && object.Numeric(right) && isOrderingOperator(operator) {
+		return newTypedError(object.TypeError,
+			"cannot compare %s with %s; did you mean a chained comparison?",
+			left.Type().Display(), right.Type().Display())
+	}
+
 	if okBoolLeft != okBoolRight {
-		return newError("type mismatch: %s %s %s", left.Type(), operator, right.Type())
+		return newTypedError(object.TypeError, "type mismatch: %s %s %s", left.Type().Display(), operator, right.Type().Display())
+	}
+
+	// arithmetic on numbers follows the rules shared with the vm
+	if isArithmeticOperator(operator) && object.Numeric(left) && object.Numeric(right) {
+		return evalArithmetic(operator, left, right)
 	}
 
 	if left.Type() == object.INTEGER_OBJ && right.Type() == object.INTEGER_OBJ {
 		return evalIntegerInfixExpression(operator, left, right)
 	}
 
-	if left.Type() == object.INTEGER_OBJ && right.Type() == object.FLOAT_OBJ {
-		value := left.(*object.Integer).Value
-		left = &object.Float{Value: float64(value)}
-		return evalFloatInfixExpression(operator, left, right)
+	// any other mix of integers and floats is promoted to floats
+	if leftVal, ok := object.ToFloat(left); ok {
+		if rightVal, ok := object.ToFloat(right); ok {
+			return evalFloatInfixExpression(operator, leftVal, rightVal)
+		}
 	}
 
-	if left.Type() == object.FLOAT_OBJ && right.Type() == object.INTEGER_OBJ {
-		value := right.(*object.Integer).Value
-		right = &object.Float{Value: float64(value)}
-		return evalFloatInfixExpression(operator, left, right)
+	if left.Type() == object.STRING_OBJ && right.Type() == object.STRING_OBJ {
+		return evalStringInfixExpression(operator, left, right)
 	}
 
-	if left.Type() == object.FLOAT_OBJ && right.Type() == object.FLOAT_OBJ {
-		return evalFloatInfixExpression(operator, left, right)
+	if left.Type() == object.STRING_OBJ && right.Type() == object.INTEGER_OBJ && operator == "*" {
+		return evalStringInfixExpression(operator, left, right)
 	}
 
-	if left.Type() == object.STRING_OBJ && right.Type() == object.STRING_OBJ {
-		return evalStringInfixExpression(operator, left, right)
+	if left.Type() == object.ARRAY_OBJ {
+		return evalArrayInfixExpression(operator, left, right)
+	}
+
+	return newTypedError(object.TypeError, "unknown operator: %s %s %s", left.Type().Display(), operator, right.Type().Display())
+}
+
+// function that determines if operator is one of <, >, <=, >=
+func isOrderingOperator(operator string) bool {
+	switch operator {
+	case "<", ">", "<=", ">=":
+		return true
+	default:
+		return false
 	}
+}
 
-	return newError("unknown operator: %s %s %s", left.Type(), operator, right.Type())
+// largest string (in bytes) or array (in elements) a repetition can produce
+const maxRepetitionSize = 1 << 24
+
+// function that checks that repeating something of the given length count
+// times stays within maxRepetitionSize, the product is never computed so
+// it can't overflow
+func checkRepetitionSize(length int, count int64) *object.Error {
+	if length > 0 && count > int64(maxRepetitionSize/length) {
+		return newError("repetition too large: %d * %d exceeds %d", length, count, maxRepetitionSize)
+	}
+	return nil
 }
 
 // function for evaluating infix operations applied to strings
 func evalStringInfixExpression(operator string, left object.Object, right object.Object) object.Object {
-	if operator != "+" {
-		return newError("unknown operator: %s %s %s",
-			left.Type(), operator, right.Type())
+	leftVal := left.(object.String).Value
+
+	switch {
+	case operator == "+" && right.Type() == object.STRING_OBJ:
+		rightVal := right.(object.String).Value
+		return object.String{Value: leftVal + rightVal}
+	case operator == "*" && right.Type() == object.INTEGER_OBJ:
+		count := right.(*object.Integer).Value
+		if count < 0 {
+			return newTypedError(object.TypeError, "negative repetition count: %d", count)
+		}
+		if err := checkRepetitionSize(len(leftVal), count); err != nil {
+			return err
+		}
+		return object.String{Value: strings.Repeat(leftVal, int(count))}
+	default:
+		return newTypedError(object.TypeError, "unknown operator: %s %s %s",
+			left.Type().Display(), operator, right.Type().Display())
 	}
+}
 
-	leftVal := left.(object.String).Value
-	rightVal := right.(object.String).Value
-	return object.String{Value: leftVal + rightVal}
+// function that flattens a chain of + (a + b + c is parsed as (a + b) + c)
+// into its operands from left to right, a single + is not a chain and
+// returns nil so that it is evaluated without allocating
+func plusChain(node ast.InfixExpression) []ast.Expression {
+	if node.Operator != "+" {
+		return nil
+	}
+	if left, ok := node.Left.(ast.InfixExpression); !ok || left.Operator != "+" {
+		return nil
+	}
+
+	operands := []ast.Expression{node.Right}
+	left := node.Left
+	for {
+		infix, ok := left.(ast.InfixExpression)
+		if !ok || infix.Operator != "+" {
+			break
+		}
+		operands = append(operands, infix.Right)
+		left = infix.Left
+	}
+	operands = append(operands, left)
+
+	for i, j := 0, len(operands)-1; i < j; i, j = i+1, j-1 {
+		operands[i], operands[j] = operands[j], operands[i]
+	}
+	return operands
 }
 
-// function for evaluating infix expression where at least operands are floats
-func evalFloatInfixExpression(operator string, left object.Object, right object.Object) object.Object {
-	leftVal := left.(*object.Float).Value
-	rightVal := right.(*object.Float).Value
+// function for evaluating a chain of + one operand at a time, runs of
+// strings are joined with a single builder instead of copying the result
+// for every + which is quadratic in the length of the chain
+func evalPlusChain(operands []ast.Expression, env *object.Environment) object.Object {
+	result := Eval(operands[0], env)
+	if isError(result) {
+		return result
+	}
+
+	var joined strings.Builder
+	joining := false
+	for _, operand := range operands[1:] {
+		right := Eval(operand, env)
+		if isError(right) {
+			return right
+		}
+
+		rightString, ok := right.(object.String)
+		if leftString, isString := result.(object.String); ok && (joining || isString) {
+			if !joining {
+				joined.Reset()
+				joined.WriteString(leftString.Value)
+				joining = true
+			}
+			joined.WriteString(rightString.Value)
+			continue
+		}
+
+		if joining {
+			result = object.String{Value: joined.String()}
+			joining = false
+		}
+		result = evalInfixExpression("+", result, right)
+		if isError(result) {
+			return result
+		}
+	}
+
+	if joining {
+		return object.String{Value: joined.String()}
+	}
+	return result
+}
+
+// function for evaluating infix operations where the left operand is an array
+// (concatenation with another array and repetition by an integer)
+// both produce a new array leaving the operands untouched
+func evalArrayInfixExpression(operator string, left object.Object, right object.Object) object.Object {
+	leftElements := left.(*object.Array).Elements
+
+	switch {
+	case operator == "+" && right.Type() == object.ARRAY_OBJ:
+		rightElements := right.(*object.Array).Elements
+		elements := make([]object.Object, 0, len(leftElements)+len(rightElements))
+		elements = append(elements, leftElements...)
+		elements = append(elements, rightElements...)
+		return &object.Array{Elements: elements}
+	case operator == "*" && right.Type() == object.INTEGER_OBJ:
+		count := right.(*object.Integer).Value
+		if count < 0 {
+			return newTypedError(object.TypeError, "negative repetition count: %d", count)
+		}
+		if err := checkRepetitionSize(len(leftElements), count); err != nil {
+			return err
+		}
+		if len(leftElements) == 0 {
+			return &object.Array{Elements: []object.Object{}}
+		}
+		elements := make([]object.Object, 0, len(leftElements)*int(count))
+		for i := int64(0); i < count; i++ {
+			elements = append(elements, leftElements...)
+		}
+		return &object.Array{Elements: elements}
+	default:
+		return newTypedError(object.TypeError, "unknown operator: %s %s %s",
+			left.Type().Display(), operator, right.Type().Display())
+	}
+}
 
+// function for evaluating infix expression where at least operands are floats
+func evalFloatInfixExpression(operator string, leftVal, rightVal float64) object.Object {
 	switch operator {
 	case "<":
 		return nativeBoolToBooleanObject(leftVal < rightVal)
 	case ">":
 		return nativeBoolToBooleanObject(leftVal > rightVal)
+	case "<=":
+		return nativeBoolToBooleanObject(leftVal <= rightVal)
+	case ">=":
+		return nativeBoolToBooleanObject(leftVal >= rightVal)
+	// equality is exact, approxEq compares with a tolerance
 	case "==":
 		return nativeBoolToBooleanObject(leftVal == rightVal)
 	case "!=":
 		return nativeBoolToBooleanObject(leftVal != rightVal)
+	default:
+		return NULL
+	}
+}
+
+// function for evaluating +, -, * and / on two numbers, integer results
+// are checked for overflow when CheckOverflow is set
+func evalArithmetic(operator string, left, right object.Object) object.Object {
+	leftInt, leftOk := left.(*object.Integer)
+	rightInt, rightOk := right.(*object.Integer)
+	if CheckOverflow && leftOk && rightOk && overflows(operator, leftInt.Value, rightInt.Value) {
+		return newError("integer overflow: %d %s %d", leftInt.Value, operator, rightInt.Value)
+	}
+
+	result, err := object.Arithmetic(operator, left, right)
+	if err != nil {
+		return newError("%s", err)
+	}
+	return result
+}
+
+// function that determines if applying operator to two integers wraps around
+func overflows(operator string, left, right int64) bool {
+	switch operator {
 	case "+":
-		return &object.Float{Value: leftVal + rightVal}
+		result := left + right
+		return left > 0 && right > 0 && result < 0 || left < 0 && right < 0 && result >= 0
 	case "-":
-		return &object.Float{Value: leftVal - rightVal}
+		result := left - right
+		return left >= 0 && right < 0 && result < 0 || left < 0 && right > 0 && result >= 0
 	case "*":
-		return &object.Float{Value: leftVal * rightVal}
-	case "/":
-		return &object.Float{Value: leftVal / rightVal}
+		result := left * right
+		return left != 0 && (result/left != right || left == -1 && right == math.MinInt64)
 	default:
-		return NULL
+		return false
+	}
+}
+
+// function that determines if operator is one of +, -, *, /
+func isArithmeticOperator(operator string) bool {
+	switch operator {
+	case "+", "-", "*", "/":
+		return true
+	default:
+		return false
 	}
 }
 
@@ -384,20 +764,16 @@ func evalIntegerInfixExpression(operator string, left object.Object, right objec
 		return nativeBoolToBooleanObject(leftVal < rightVal)
 	case ">":
 		return nativeBoolToBooleanObject(leftVal > rightVal)
+	case "<=":
+		return nativeBoolToBooleanObject(leftVal <= rightVal)
+	case ">=":
+		return nativeBoolToBooleanObject(leftVal >= rightVal)
 	case "==":
 		return nativeBoolToBooleanObject(leftVal == rightVal)
 	case "!=":
 		return nativeBoolToBooleanObject(leftVal != rightVal)
-	case "+":
-		return &object.Integer{Value: leftVal + rightVal}
-	case "-":
-		return &object.Integer{Value: leftVal - rightVal}
-	case "*":
-		return &object.Integer{Value: leftVal * rightVal}
-	case "/":
-		return &object.Integer{Value: leftVal / rightVal}
 	default:
-		return newError("unknown operator: %s %s %s", left.Type(), operator, right.Type())
+		return newTypedError(object.TypeError, "unknown operator: %s %s %s", left.Type().Display(), operator, right.Type().Display())
 	}
 }
 
@@ -408,8 +784,10 @@ func evalPrefixExpression(operator string, right object.Object) object.Object {
 		return evalBangOperator(right)
 	case "-":
 		return evalMinusPrefixOperatorExpression(right)
+	case "+":
+		return evalPlusPrefixOperatorExpression(right)
 	default:
-		return newError("unknown operator: %s%s", operator, right.Type())
+		return newTypedError(object.TypeError, "unknown operator: %s%s", operator, right.Type().Display())
 	}
 }
 
@@ -424,21 +802,21 @@ func evalMinusPrefixOperatorExpression(right object.Object) object.Object {
 		return &object.Float{Value: -value}
 	}
 
-	return newError("unknown operator: -%s", right.Type())
+	return newTypedError(object.TypeError, "unknown operator: -%s", right.Type().Display())
+}
+
+// function for evaluating plus operator (no-op for numbers)
+func evalPlusPrefixOperatorExpression(right object.Object) object.Object {
+	if right.Type() == object.INTEGER_OBJ || right.Type() == object.FLOAT_OBJ {
+		return right
+	}
+
+	return newTypedError(object.TypeError, "unknown operator: +%s", right.Type().Display())
 }
 
 // function for evaluating bang operator
 func evalBangOperator(right object.Object) object.Object {
-	switch right {
-	case TRUE:
-		return FALSE
-	case FALSE:
-		return TRUE
-	case NULL:
-		return TRUE
-	default:
-		return FALSE
-	}
+	return nativeBoolToBooleanObject(!object.IsTruthy(right))
 }
 
 // function that takes ast.Boolean and returns reference to
@@ -461,6 +839,8 @@ func evalProgram(stmts []ast.Statement, env *object.Environment) object.Object {
 			return result.Value
 		case *object.Error:
 			return result
+		case *object.Break, *object.Continue:
+			return checkLoopControl(result)
 		}
 	}
 
@@ -1,8 +1,13 @@
 package eval
 
 import (
+	"fmt"
+	"math"
+	"strings"
 	"testing"
+	"time"
 
+	"github.com/stevensopilidis/monkey/ast"
 	"github.com/stevensopilidis/monkey/lexer"
 	"github.com/stevensopilidis/monkey/object"
 	"github.com/stevensopilidis/monkey/parser"
@@ -54,6 +59,32 @@ func TestHashIndexExpressions(t *testing.T) {
 	}
 }
 
+func TestHashMixedKeyTypes(t *testing.T) {
+	// 1 and true both hash to the value 1, the type of the key keeps them apart
+	input := `let h = {1: "int", true: "bool", "1": "string", 0: "zero", false: "false"};`
+	testCases := []struct {
+		index    string
+		expected string
+	}{
+		{"1", "int"},
+		{"true", "bool"},
+		{`"1"`, "string"},
+		{"0", "zero"},
+		{"false", "false"},
+	}
+
+	for _, tc := range testCases {
+		evaluated := testEval(input + "h[" + tc.index + "]")
+		str, ok := evaluated.(object.String)
+		require.True(t, ok)
+		require.Equal(t, tc.expected, str.Value)
+	}
+
+	hash, ok := testEval(input + "h").(*object.Hash)
+	require.True(t, ok)
+	require.Len(t, hash.Pairs, 5)
+}
+
 func TestHashLiterals(t *testing.T) {
 	input := `let two = "two";
 	{
@@ -144,6 +175,38 @@ func TestArrayIndexExpressions(t *testing.T) {
 	}
 }
 
+func TestIndexAssignment(t *testing.T) {
+	testCases := []struct {
+		input    string
+		expected interface{}
+	}{
+		{"let a = [1, 2, 3]; a[0] = 99; a[0]", 99},
+		{"let a = [1, 2, 3]; a[2] = a[0] + a[1]; a", []int64{1, 2, 3}},
+		{"let a = [1, 2, 3]; a[1] = 5", 5},
+		{"let a = [[1], [2]]; a[1][0] = 7; a[1][0]", 7},
+		{"let a = [1, 2]; let b = push(a, 3); a[0] = 9; b[0]", 1},
+		{"let a = [1, 2, 3]; let r = rest(a); r[0] = 9; a[1]", 2},
+		{`let h = {"k": 0}; h["k"] = 1; h["k"]`, 1},
+		{`let h = {}; h["new"] = 2; h["new"]`, 2},
+		{"let h = {}; let set = fn(k, v) { h[k] = v }; set(true, 3); h[true]", 3},
+	}
+
+	for _, tc := range testCases {
+		evaluated := testEval(tc.input)
+		switch expected := tc.expected.(type) {
+		case int:
+			testIntegerObject(t, evaluated, int64(expected))
+		case []int64:
+			array, ok := evaluated.(*object.Array)
+			require.True(t, ok)
+			require.Equal(t, len(expected), len(array.Elements))
+			for i, v := range expected {
+				testIntegerObject(t, array.Elements[i], v)
+			}
+		}
+	}
+}
+
 func TestArrayLiterals(t *testing.T) {
 	input := "[1, 2 * 2, 3 + 3]"
 
@@ -165,7 +228,12 @@ func TestBuiltinFunctions(t *testing.T) {
 		{`len("")`, 0},
 		{`len("four")`, 4},
 		{`len("hello world")`, 11},
-		{`len(1)`, "argument to `len` not supported, got INTEGER"},
+		{`len("héllo")`, 5},
+		{`len("日本語")`, 3},
+		{"len(`a\\nb`)", 4},
+		{`len({"a": 1, "b": 2})`, 2},
+		{`len({})`, 0},
+		{`len(1)`, "argument to `len` not supported, got integer"},
 		{`len("one", "two")`, "wrong number of arguments. got=2, want=1"},
 	}
 
@@ -182,6 +250,130 @@ func TestBuiltinFunctions(t *testing.T) {
 	}
 }
 
+func TestPushAndRestLeaveInputsAlone(t *testing.T) {
+	testCases := []struct {
+		input    string
+		expected string
+	}{
+		{"let a = [1]; let b = push(a, 2); let c = push(a, 3); [a, b, c]", "[[1], [1, 2], [1, 3]]"},
+		{"let a = push(push([], 1), 2); let b = push(a, 3); let c = push(a, 4); [a, b, c]", "[[1, 2], [1, 2, 3], [1, 2, 4]]"},
+		{"let a = [1, 2, 3]; let b = rest(a); let c = push(rest(b), 4); [a, b, c]", "[[1, 2, 3], [2, 3], [3, 4]]"},
+	}
+
+	for _, tc := range testCases {
+		require.Equal(t, tc.expected, testEval(tc.input).Inspect())
+	}
+}
+
+func TestContainsBuiltin(t *testing.T) {
+	testCases := []struct {
+		input    string
+		expected interface{}
+	}{
+		{`contains([1, 2, 3], 2)`, true},
+		{`contains([1, 2, 3], 4)`, false},
+		{`contains([[1, 2], "a"], [1, 2])`, true},
+		{`contains([1, 2, 3], "1")`, false},
+		{`contains({"a": 1, 2: 3}, "a")`, true},
+		{`contains({"a": 1, 2: 3}, 2)`, true},
+		{`contains({"a": 1, 2: 3}, "b")`, false},
+		{`contains("monkey", "key")`, true},
+		{`contains("monkey", "donkey")`, false},
+		{`contains(1, 1)`, "argument to `contains` not supported, got integer"},
+		{`contains("monkey", 1)`, "second argument to `contains` must be string, got integer"},
+		{`contains([1])`, "wrong number of arguments. got=1, want=2"},
+	}
+
+	for _, tc := range testCases {
+		evaluated := testEval(tc.input)
+		switch expected := tc.expected.(type) {
+		case bool:
+			testBooleanObject(t, evaluated, expected)
+		case string:
+			errObj, ok := evaluated.(*object.Error)
+			require.True(t, ok)
+			require.Equal(t, expected, errObj.Message)
+		}
+	}
+}
+
+func TestBuiltinsSharedWithCompiler(t *testing.T) {
+	for _, b := range object.Builtins {
+		require.Same(t, b.Builtin, Builtins[b.Name], b.Name)
+	}
+	require.Equal(t, len(object.Builtins)+2, len(Builtins))
+	require.NotNil(t, Builtins["apply"])
+	require.NotNil(t, Builtins["sort"])
+}
+
+func TestNumericBuiltins(t *testing.T) {
+	testCases := []struct {
+		input    string
+		expected interface{}
+	}{
+		{`abs(-3)`, 3},
+		{`abs(3)`, 3},
+		{`abs(-2.5)`, 2.5},
+		{`min(3, 1, 2)`, 1},
+		{`min(3, 1.5, 2)`, 1.5},
+		{`min(3, 1, 2.5)`, 1.0},
+		{`max(1.5, 2)`, 2.0},
+		{`max(4, 1, 2)`, 4},
+		{`max(7)`, 7},
+		{`abs("a")`, "argument to `abs` must be integer or float, got string"},
+		{`min()`, "wrong number of arguments. got=0, want at least 1"},
+		{`max(1, true)`, "argument to `max` must be integer or float, got boolean"},
+	}
+
+	for _, tc := range testCases {
+		evaluated := testEval(tc.input)
+		switch expected := tc.expected.(type) {
+		case int:
+			testIntegerObject(t, evaluated, int64(expected))
+		case float64:
+			testFloatObject(t, evaluated, expected)
+		case string:
+			errObj, ok := evaluated.(*object.Error)
+			require.True(t, ok)
+			require.Equal(t, expected, errObj.Message)
+		}
+	}
+}
+
+func TestRangeBuiltin(t *testing.T) {
+	testCases := []struct {
+		input    string
+		expected interface{}
+	}{
+		{`range(3)`, []int64{0, 1, 2}},
+		{`range(0)`, []int64{}},
+		{`range(1, 4)`, []int64{1, 2, 3}},
+		{`range(0, 10, 2)`, []int64{0, 2, 4, 6, 8}},
+		{`range(3, 0, -1)`, []int64{3, 2, 1}},
+		{`range(0, 10, 0)`, "step of `range` must not be zero"},
+		{`range(5, 1)`, "end of `range` is unreachable: start=5, end=1, step=1"},
+		{`range("a")`, "argument to `range` must be integer, got string"},
+		{`range()`, "wrong number of arguments. got=0, want=1, 2 or 3"},
+	}
+
+	for _, tc := range testCases {
+		evaluated := testEval(tc.input)
+		switch expected := tc.expected.(type) {
+		case []int64:
+			array, ok := evaluated.(*object.Array)
+			require.True(t, ok)
+			require.Equal(t, len(expected), len(array.Elements))
+			for i, el := range expected {
+				testIntegerObject(t, array.Elements[i], el)
+			}
+		case string:
+			errObj, ok := evaluated.(*object.Error)
+			require.True(t, ok)
+			require.Equal(t, expected, errObj.Message)
+		}
+	}
+}
+
 func TestClosures(t *testing.T) {
 	input := `
 	let newAdder = fn(x) {
@@ -192,6 +384,46 @@ func TestClosures(t *testing.T) {
 	testIntegerObject(t, testEval(input), 4)
 }
 
+func TestMutualRecursion(t *testing.T) {
+	testCases := []struct {
+		input    string
+		expected string
+	}{
+		{`
+		let isEven = fn(n) { if (n == 0) { true } else { isOdd(n - 1) } };
+		let isOdd = fn(n) { if (n == 0) { false } else { isEven(n - 1) } };
+		[isEven(10), isOdd(7), isEven(3), isOdd(0)]
+		`, "[true, true, false, false]"},
+		// the same between the locals of a function
+		{`
+		let parity = fn(n) {
+			let even = fn(n) { if (n == 0) { "even" } else { odd(n - 1) } };
+			let odd = fn(n) { if (n == 0) { "odd" } else { even(n - 1) } };
+			even(n)
+		};
+		[parity(4), parity(5)]
+		`, "[even, odd]"},
+		// anonymous recursion by passing the function to itself
+		{"(fn(f) { f(f, 5) })(fn(self, n) { if (n == 0) { 1 } else { n * self(self, n - 1) } })", "120"},
+		{`
+		let Y = fn(f) { (fn(x) { x(x) })(fn(x) { f(fn(n) { x(x)(n) }) }) };
+		let fact = Y(fn(self) { fn(n) { if (n == 0) { 1 } else { n * self(n - 1) } } });
+		fact(5)
+		`, "120"},
+		// calling a function before what it calls is defined still fails
+		{`
+		let isEven = fn(n) { if (n == 0) { true } else { isOdd(n - 1) } };
+		let r = isEven(1);
+		let isOdd = fn(n) { true };
+		r
+		`, "ERROR[NameError]: identifier not found: isOdd"},
+	}
+
+	for _, tc := range testCases {
+		require.Equal(t, tc.expected, testEval(tc.input).Inspect(), tc.input)
+	}
+}
+
 func TestFunctionApplication(t *testing.T) {
 	testCases := []struct {
 		input    string
@@ -209,6 +441,59 @@ func TestFunctionApplication(t *testing.T) {
 	}
 }
 
+func TestApplyCompiledFunction(t *testing.T) {
+	fn := &object.CompiledFunction{Name: "add", NumParameters: 2}
+
+	evaluated := applyFunction(fn, []object.Object{
+		&object.Integer{Value: 1},
+		&object.Integer{Value: 2},
+	})
+
+	errObj, ok := evaluated.(*object.Error)
+	require.True(t, ok)
+	require.Equal(t, "cannot call compiled function in interpreter mode", errObj.Message)
+
+	evaluated = applyFunction(&object.Closure{Fn: fn}, []object.Object{})
+	errObj, ok = evaluated.(*object.Error)
+	require.True(t, ok)
+	require.Equal(t, "cannot call compiled function in interpreter mode", errObj.Message)
+}
+
+func TestLambdas(t *testing.T) {
+	testCases := []struct {
+		input    string
+		expected int64
+	}{
+		{`let add = \(x, y) -> x + y; add(2, 3)`, 5},
+		{`(\() -> 7)()`, 7},
+		{`let adder = \(x) -> \(y) -> x + y; adder(2)(5)`, 7},
+		{`apply(\(a, b) -> a * b, [3, 4])`, 12},
+		{`let twice = fn(f, x) { f(f(x)) }; twice(\(x) -> x * 2, 3)`, 12},
+	}
+
+	for _, tc := range testCases {
+		testIntegerObject(t, testEval(tc.input), tc.expected)
+	}
+}
+
+func TestExpressionBodiedFunctions(t *testing.T) {
+	testCases := []struct {
+		input      string
+		equivalent string
+	}{
+		{"let add = fn(x, y) => x + y; add(2, 3)", "let add = fn(x, y) { x + y }; add(2, 3)"},
+		{"(fn() => 7)()", "(fn() { 7 })()"},
+		{"let adder = fn(x) => fn(y) => x + y; adder(2)(5)", "let adder = fn(x) { fn(y) { x + y } }; adder(2)(5)"},
+		{"let f = fn(n) => if (n > 1) { n * f(n - 1) } else { 1 }; f(5)", "let f = fn(n) { if (n > 1) { n * f(n - 1) } else { 1 } }; f(5)"},
+		{"sort([1, 3, 2], fn(a, b) => b - a)", "sort([1, 3, 2], fn(a, b) { b - a })"},
+	}
+
+	for _, tc := range testCases {
+		require.Equal(t, testEval(tc.equivalent).Inspect(), testEval(tc.input).Inspect(), tc.input)
+	}
+	testIntegerObject(t, testEval("let add = fn(x, y) => x + y; add(2, 3)"), 5)
+}
+
 func TestStringLiteral(t *testing.T) {
 	input := `"Hello World!"`
 	evaluated := testEval(input)
@@ -218,6 +503,111 @@ func TestStringLiteral(t *testing.T) {
 	require.Equal(t, "Hello World!", str.Value)
 }
 
+func TestStringIndexExpressions(t *testing.T) {
+	testCases := []struct {
+		input    string
+		expected interface{}
+	}{
+		{`"hello"[1]`, "e"},
+		{`let s = "hello"; s[len(s) - 1]`, "o"},
+		{`"héllo"[1]`, "é"},
+		{`"hello"[5]`, nil},
+		{`"hello"[-1]`, nil},
+		{`""[0]`, nil},
+	}
+
+	for _, tc := range testCases {
+		evaluated := testEval(tc.input)
+		expected, ok := tc.expected.(string)
+		if !ok {
+			testNullObject(t, evaluated)
+			continue
+		}
+		str, ok := evaluated.(object.String)
+		require.True(t, ok)
+		require.Equal(t, expected, str.Value)
+	}
+}
+
+func TestPlusChains(t *testing.T) {
+	parts := make([]string, 100)
+	var expected strings.Builder
+	for i := range parts {
+		parts[i] = fmt.Sprintf("%q", fmt.Sprint(i))
+		expected.WriteString(fmt.Sprint(i))
+	}
+
+	str, ok := testEval(strings.Join(parts, " + ")).(object.String)
+	require.True(t, ok)
+	require.Equal(t, expected.String(), str.Value)
+
+	testCases := []struct {
+		input    string
+		expected string
+	}{
+		{`let s = "b"; "a" + s + "c" + s`, "abcb"},
+		{`"a" + ("b" + "c") + "d"`, "abcd"},
+		{"1 + 2 + 3 + 4", "10"},
+		{"1 + 2.5 + 3", "6.500000"},
+		{"[1] + [2] + [3]", "[1, 2, 3]"},
+		{`1 + 2 + "a"`, "ERROR[TypeError]: unknown operator: integer + string"},
+		{`"a" + "b" + 1 + "c"`, "ERROR[TypeError]: unknown operator: string + integer"},
+		{`"a" + "b" + missing`, "ERROR[NameError]: identifier not found: missing"},
+	}
+
+	for _, tc := range testCases {
+		require.Equal(t, tc.expected, testEval(tc.input).Inspect(), tc.input)
+	}
+}
+
+func TestPlusChainOperands(t *testing.T) {
+	testCases := []struct {
+		input    string
+		expected int
+	}{
+		{"1 + 2", 0},
+		{"1 * 2", 0},
+		{"(1 + 2) * 3", 0},
+		{"1 + (2 + 3)", 0},
+		{"1 + 2 + 3", 3},
+		{"1 + 2 * 3 + 4 + 5", 4},
+	}
+
+	for _, tc := range testCases {
+		program := parser.New(lexer.New(tc.input)).ParseProgram()
+		stmt := program.Statements[0].(ast.ExpressionStatement)
+		infix := stmt.Expression.(ast.InfixExpression)
+		require.Len(t, plusChain(infix), tc.expected, tc.input)
+	}
+}
+
+// a long chain of string + folded pair by pair the way it used to be
+// evaluated, copying the result every time, against the chain evaluation
+func BenchmarkStringConcatenation(b *testing.B) {
+	const size = 1000
+
+	parts := make([]string, size)
+	for i := range parts {
+		parts[i] = `"monkey"`
+	}
+	program := parser.New(lexer.New(strings.Join(parts, " + "))).ParseProgram()
+
+	b.Run("pairwise", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			var result object.Object = object.String{Value: "monkey"}
+			for j := 1; j < size; j++ {
+				result = evalInfixExpression("+", result, object.String{Value: "monkey"})
+			}
+		}
+	})
+
+	b.Run("chain", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			Eval(program, object.NewEnvironment())
+		}
+	})
+}
+
 func TestStringConcatenation(t *testing.T) {
 	input := `"Hello" + " " + "World!"`
 	evaluated := testEval(input)
@@ -227,6 +617,75 @@ func TestStringConcatenation(t *testing.T) {
 	require.Equal(t, "Hello World!", str.Value)
 }
 
+func TestArrayOperators(t *testing.T) {
+	testCases := []struct {
+		input    string
+		expected interface{}
+	}{
+		{"[1, 2] + [3, 4]", []int64{1, 2, 3, 4}},
+		{"[] + [1]", []int64{1}},
+		{"[0] * 3", []int64{0, 0, 0}},
+		{"[1, 2] * 2", []int64{1, 2, 1, 2}},
+		{"[1] * 0", []int64{}},
+		{"let a = [1, 2]; let b = [3]; let c = a + b; a", []int64{1, 2}},
+		{"let a = [1, 2]; let b = [3]; let c = a + b; b", []int64{3}},
+		{"let a = [1]; let b = a * 3; a", []int64{1}},
+		{"[1] * [2]", "unknown operator: array * array"},
+		{"[1] - [2]", "unknown operator: array - array"},
+		{"[1] + 1", "unknown operator: array + integer"},
+		{"[1] * -1", "negative repetition count: -1"},
+		{"[1] * 9223372036854775807", "repetition too large: 1 * 9223372036854775807 exceeds 16777216"},
+		{"[1, 2] * 8388609", "repetition too large: 2 * 8388609 exceeds 16777216"},
+		{"[] * 9223372036854775807", []int64{}},
+	}
+
+	for _, tc := range testCases {
+		evaluated := testEval(tc.input)
+		switch expected := tc.expected.(type) {
+		case []int64:
+			array, ok := evaluated.(*object.Array)
+			require.True(t, ok)
+			require.Equal(t, len(expected), len(array.Elements))
+			for i, el := range expected {
+				testIntegerObject(t, array.Elements[i], el)
+			}
+		case string:
+			errObj, ok := evaluated.(*object.Error)
+			require.True(t, ok)
+			require.Equal(t, expected, errObj.Message)
+		}
+	}
+}
+
+func TestStringRepetition(t *testing.T) {
+	testCases := []struct {
+		input    string
+		expected string
+	}{
+		{`"ab" * 2`, "abab"},
+		{`"ab" * 0`, ""},
+		{`let s = "ab"; let r = s * 3; s`, "ab"},
+	}
+
+	for _, tc := range testCases {
+		str, ok := testEval(tc.input).(object.String)
+		require.True(t, ok)
+		require.Equal(t, tc.expected, str.Value)
+	}
+
+	errObj, ok := testEval(`"ab" * "ab"`).(*object.Error)
+	require.True(t, ok)
+	require.Equal(t, "unknown operator: string * string", errObj.Message)
+
+	errObj, ok = testEval(`"ab" * 9223372036854775807`).(*object.Error)
+	require.True(t, ok)
+	require.Equal(t, "repetition too large: 2 * 9223372036854775807 exceeds 16777216", errObj.Message)
+
+	str, ok := testEval(`"" * 9223372036854775807`).(object.String)
+	require.True(t, ok)
+	require.Equal(t, "", str.Value)
+}
+
 func TestFunctionObject(t *testing.T) {
 	input := "fn(x) { x + 2; }"
 
@@ -255,6 +714,48 @@ func TestLetStatements(t *testing.T) {
 	}
 }
 
+func TestDestructuringLetStatements(t *testing.T) {
+	testCases := []struct {
+		input    string
+		expected interface{}
+	}{
+		{"let [a, b] = [1, 2]; a;", 1},
+		{"let [a, b] = [1, 2]; b;", 2},
+		{"let [a, b] = [1, 2]; let [a, b] = [b, a]; a - b;", 1},
+		{"let f = fn() { let [x, y] = [3, 4]; x * y }; f();", 12},
+		{"let [head, ...tail] = [1, 2, 3]; tail;", "[2, 3]"},
+		{"let [head, ...tail] = [1]; tail;", "[]"},
+		{"let [a, b] = [1];", "wrong number of values to destructure: want=2, got=1"},
+		{"let [a] = [1, 2];", "wrong number of values to destructure: want=1, got=2"},
+		{"let [a, b, ...c] = [1];", "not enough values to destructure: want at least 2, got=1"},
+		{"let [a, b] = 5;", "cannot destructure integer, expected array"},
+		{"let divmod = fn(a, b) { return a / b, a - a / b * b; }; let q, r = divmod(17, 5); q", 3},
+		{"let divmod = fn(a, b) { return a / b, a - a / b * b; }; let q, r = divmod(17, 5); r", 2},
+		{"let f = fn() { return 1, 2, 3; }; f()", "[1, 2, 3]"},
+		{"let f = fn() { return 1, 2, 3; }; let first, ...others = f(); others", "[2, 3]"},
+		{"let f = fn() { return 1, 2, 3; }; let a, b = f();", "wrong number of values to destructure: want=2, got=3"},
+	}
+
+	for _, tc := range testCases {
+		evaluated := testEval(tc.input)
+		switch expected := tc.expected.(type) {
+		case int:
+			testIntegerObject(t, evaluated, int64(expected))
+		case string:
+			if errObj, ok := evaluated.(*object.Error); ok {
+				require.Equal(t, expected, errObj.Message)
+				require.Equal(t, object.TypeError, errObj.Kind)
+			} else {
+				require.Equal(t, expected, evaluated.Inspect())
+			}
+		}
+	}
+
+	// the rest element is a copy, not a view of the destructured array
+	evaluated := testEval("let xs = [1, 2, 3]; let [a, ...rest] = xs; let rest = push(rest, 4); xs;")
+	require.Equal(t, "[1, 2, 3]", evaluated.Inspect())
+}
+
 func TestErrorHandling(t *testing.T) {
 	testCases := []struct {
 		input                string
@@ -262,27 +763,27 @@ func TestErrorHandling(t *testing.T) {
 	}{
 		{
 			"5 + true;",
-			"type mismatch: INTEGER + BOOLEAN",
+			"type mismatch: integer + boolean",
 		},
 		{
 			"5 + true; 5;",
-			"type mismatch: INTEGER + BOOLEAN",
+			"type mismatch: integer + boolean",
 		},
 		{
 			"-true",
-			"unknown operator: -BOOLEAN",
+			"unknown operator: -boolean",
 		},
 		{
 			"true + false;",
-			"unknown operator: BOOLEAN + BOOLEAN",
+			"unknown operator: boolean + boolean",
 		},
 		{
 			"5; true + false; 5",
-			"unknown operator: BOOLEAN + BOOLEAN",
+			"unknown operator: boolean + boolean",
 		},
 		{
 			"if (10 > 1) { true + false; }",
-			"unknown operator: BOOLEAN + BOOLEAN",
+			"unknown operator: boolean + boolean",
 		},
 		{
 			`
@@ -293,7 +794,19 @@ func TestErrorHandling(t *testing.T) {
 				return 1;
 			}
 			`,
-			"unknown operator: BOOLEAN + BOOLEAN",
+			"unknown operator: boolean + boolean",
+		},
+		{
+			"+true",
+			"unknown operator: +boolean",
+		},
+		{
+			"1 < 2 < 3",
+			"cannot compare boolean with integer; did you mean a chained comparison?",
+		},
+		{
+			"3 > 2 > 1.5",
+			"cannot compare boolean with float; did you mean a chained comparison?",
 		},
 		{
 			"foobar",
@@ -301,11 +814,47 @@ func TestErrorHandling(t *testing.T) {
 		},
 		{
 			`"Hello" - "World"`,
-			"unknown operator: STRING - STRING",
+			"unknown operator: string - string",
 		},
 		{
 			`{"name": "Monkey"}[fn(x) { x }];`,
-			"unusable as hash key: FUNCTION",
+			"unusable as hash key: function",
+		},
+		{
+			`{}[fn(x) { x }]`,
+			"unusable as hash key: function",
+		},
+		{
+			`{fn(x) { x }: 1}`,
+			"unusable as hash key: function",
+		},
+		{
+			"10 / (5 - 5)",
+			"division by zero: 10 / 0",
+		},
+		{
+			"let a = [1, 2, 3]; a[3] = 1",
+			"index out of range: 3 (length 3)",
+		},
+		{
+			"let a = [1, 2, 3]; a[-1] = 1",
+			"index out of range: -1 (length 3)",
+		},
+		{
+			`let a = [1]; a["0"] = 1`,
+			"array index must be integer, got string",
+		},
+		{
+			`let h = {}; h[fn(x) { x }] = 1`,
+			"unusable as hash key: function",
+		},
+		{
+			`let s = "abc"; s[0] = "x"`,
+			"index assignment not supported: string",
+		},
+		{
+			`{[1]: 1}`,
+			"unusable as hash key: array",
 		},
 	}
 
@@ -319,6 +868,25 @@ func TestErrorHandling(t *testing.T) {
 	}
 }
 
+func TestErrorKinds(t *testing.T) {
+	testCases := []struct {
+		input        string
+		expectedKind object.ErrorKind
+	}{
+		{"5 + true;", object.TypeError},
+		{"-true", object.TypeError},
+		{"foobar", object.NameError},
+		{`{}[fn(x) { x }]`, object.TypeError},
+		{"[1][1] = 2", object.IndexError},
+	}
+
+	for _, tc := range testCases {
+		errObj, ok := testEval(tc.input).(*object.Error)
+		require.True(t, ok)
+		require.Equal(t, tc.expectedKind, errObj.Kind)
+	}
+}
+
 func TestReturnStatements(t *testing.T) {
 	testCases := []struct {
 		input    string
@@ -343,6 +911,29 @@ func TestReturnStatements(t *testing.T) {
 	}
 }
 
+func TestDoExpressions(t *testing.T) {
+	testCases := []struct {
+		input    string
+		expected interface{}
+	}{
+		{"let x = do { let a = 2; a * 3 }; x;", 6},
+		{"do { 1; 2 }", 2},
+		{"let f = fn() { let y = do { 5 }; y + 1 }; f();", 6},
+		{"do { }", nil},
+		{"do { let a = 1; }", nil},
+	}
+
+	for _, tc := range testCases {
+		evaluated := testEval(tc.input)
+		expectedValue, ok := tc.expected.(int)
+		if ok {
+			testIntegerObject(t, evaluated, int64(expectedValue))
+		} else {
+			testNullObject(t, evaluated)
+		}
+	}
+}
+
 func TestIfElseExpressions(t *testing.T) {
 	testCases := []struct {
 		input    string
@@ -355,6 +946,11 @@ func TestIfElseExpressions(t *testing.T) {
 		{"if (1 > 2) { 10 }", nil},
 		{"if (1 > 2) { 10 } else { 20 }", 20},
 		{"if (1 < 2) { 10 } else { 20 }", 10},
+		{"let x = 1; if (x == 1) { 10 } else if (x == 2) { 20 } else { 30 }", 10},
+		{"let x = 2; if (x == 1) { 10 } else if (x == 2) { 20 } else { 30 }", 20},
+		{"let x = 3; if (x == 1) { 10 } else if (x == 2) { 20 } else { 30 }", 30},
+		{"let x = 3; if (x == 1) { 10 } else if (x == 2) { 20 }", nil},
+		{"let x = 4; if (x == 1) { 1 } else if (x == 2) { 2 } else if (x == 3) { 3 } else if (x == 4) { 4 }", 4},
 	}
 
 	for _, tc := range testCases {
@@ -412,6 +1008,11 @@ func TestEvalIntegerExpression(t *testing.T) {
 		{"3 * (3 * 3) + 10", 37},
 		{"(5 + 10 * 2 + 15 / 3) * 2 + -10", 50},
 		{"(10 + 5) * 2", 30},
+		{"+5", 5},
+		{"+5 + +5", 10},
+		{"--5", 5},
+		{"- -5", 5},
+		{"-(-5)", 5},
 	}
 
 	for _, tc := range testCases {
@@ -420,6 +1021,41 @@ func TestEvalIntegerExpression(t *testing.T) {
 	}
 }
 
+func TestIntegerOverflow(t *testing.T) {
+	CheckOverflow = true
+	defer func() { CheckOverflow = false }()
+
+	testCases := []struct {
+		input    string
+		expected interface{}
+	}{
+		{"4611686018427387904 * 2", "integer overflow: 4611686018427387904 * 2"},
+		{"3037000500 * 3037000500", "integer overflow: 3037000500 * 3037000500"},
+		{"3037000499 * 3037000499", 9223372030926249001},
+		{"9223372036854775807 + 1", "integer overflow: 9223372036854775807 + 1"},
+		{"-9223372036854775807 - 2", "integer overflow: -9223372036854775807 - 2"},
+		{"9223372036854775806 + 1", 9223372036854775807},
+		{"-5 * 3", -15},
+	}
+
+	for _, tc := range testCases {
+		evaluated := testEval(tc.input)
+		switch expected := tc.expected.(type) {
+		case int:
+			testIntegerObject(t, evaluated, int64(expected))
+		case string:
+			errObj, ok := evaluated.(*object.Error)
+			require.True(t, ok)
+			require.Equal(t, expected, errObj.Message)
+		}
+	}
+}
+
+func TestIntegerOverflowUnchecked(t *testing.T) {
+	evaluated := testEval("9223372036854775807 + 1")
+	testIntegerObject(t, evaluated, math.MinInt64)
+}
+
 func TestEvalBooleanExpression(t *testing.T) {
 	testCases := []struct {
 		input    string
@@ -437,6 +1073,12 @@ func TestEvalBooleanExpression(t *testing.T) {
 		{"1.2341 > 2.234", false},
 		{"1.21 < 1.21", false},
 		{"1.54 > 1.54", false},
+		{"1 <= 1", true},
+		{"2 >= 3", false},
+		{"1 <= 2", true},
+		{"3 >= 2", true},
+		{"1.5 <= 1.5", true},
+		{"2.5 >= 3", false},
 		{"(1 < 2) == true", true},
 		{"(1 < 2) == false", false},
 		{"(1 > 2) == true", false},
@@ -473,6 +1115,14 @@ func TestEvalFloatExpression(t *testing.T) {
 		{"6 * 34.23", 205.38},
 		{"7.21 - 10.42 + (20.28 - 34.28) * 2", -31.21},
 		{"7.2 - 0.2 + 1.2 * 2", 9.4},
+		{"+5.5", 5.5},
+		{"-.5", -0.5},
+		{"-0.5", -0.5},
+		{"--.5", 0.5},
+		{"1.5e-3", 0.0015},
+		{"1.5e-3 * 1000", 1.5},
+		{"2e2 + 1", 201},
+		{"-1e1", -10},
 	}
 
 	for _, tc := range testCases {
@@ -514,3 +1164,434 @@ func testIntegerObject(t *testing.T, obj object.Object, expected int64) {
 
 	require.Equal(t, expected, result.Value)
 }
+
+func TestLogicalOperators(t *testing.T) {
+	testCases := []struct {
+		input    string
+		expected interface{}
+	}{
+		{"true && true", true},
+		{"true && false", false},
+		{"false && true", false},
+		{"false && false", false},
+		{"true || true", true},
+		{"true || false", true},
+		{"false || true", true},
+		{"false || false", false},
+		{"1 && 2", 2},
+		{"0 || 3", 0},
+		{"if (false) { 1 } || 5", 5},
+		// the right operand would be an error if it was evaluated
+		{"false && foobar", false},
+		{"true || foobar", true},
+		{"not false and true", true},
+		{"false or not true", false},
+	}
+
+	for _, tc := range testCases {
+		evaluated := testEval(tc.input)
+		switch expected := tc.expected.(type) {
+		case bool:
+			testBooleanObject(t, evaluated, expected)
+		case int:
+			testIntegerObject(t, evaluated, int64(expected))
+		}
+	}
+
+	evaluated := testEval("true && foobar")
+	errObj, ok := evaluated.(*object.Error)
+	require.True(t, ok)
+	require.Equal(t, "identifier not found: foobar", errObj.Message)
+}
+
+func TestNullishCoalescing(t *testing.T) {
+	testCases := []struct {
+		input    string
+		expected string
+	}{
+		{"[][0] ?? 5", "5"},
+		{"3 ?? 5", "3"},
+		{"false ?? 5", "false"},
+		{"0 ?? 5", "0"},
+		{`let h = {"a": 1}; h["a"] ?? 0`, "1"},
+		{`let h = {"a": 1}; h["b"] ?? 0`, "0"},
+		{"[][0] ?? [][1] ?? 7", "7"},
+		{"[][0] ?? [][1]", "null"},
+		{"if (false) { 1 } ?? 2 + 3", "5"},
+		{"fn() {}() ?? 5", "5"},
+		// the right operand would be an error if it was evaluated
+		{"3 ?? foobar", "3"},
+		{"[][0] ?? foobar", "ERROR[NameError]: identifier not found: foobar"},
+		// and its side effects don't happen
+		{`let h = {}; let f = fn() { h["called"] = true }; 3 ?? f(); h["called"]`, "null"},
+		{`let h = {}; let f = fn() { h["called"] = true }; [][0] ?? f(); h["called"]`, "true"},
+	}
+
+	for _, tc := range testCases {
+		require.Equal(t, tc.expected, testEval(tc.input).Inspect(), tc.input)
+	}
+}
+
+func TestApplyBuiltin(t *testing.T) {
+	testCases := []struct {
+		input    string
+		expected interface{}
+	}{
+		{"apply(fn(a, b) { a + b }, [2, 3])", 5},
+		{"let add = fn(a, b, c) { a + b + c }; apply(add, [1, 2, 3])", 6},
+		{"apply(fn() { 7 }, [])", 7},
+		{"apply(len, [[1, 2, 3]])", 3},
+		{"apply(fn(a, b) { a + b }, [2])", "wrong number of arguments: want=2, got=1"},
+		{"apply(1, [2])", "first argument to `apply` must be function or builtin, got integer"},
+		{"apply(len, 1)", "second argument to `apply` must be array, got integer"},
+		{"apply(len)", "wrong number of arguments. got=1, want=2"},
+	}
+
+	for _, tc := range testCases {
+		evaluated := testEval(tc.input)
+		switch expected := tc.expected.(type) {
+		case int:
+			testIntegerObject(t, evaluated, int64(expected))
+		case string:
+			errObj, ok := evaluated.(*object.Error)
+			require.True(t, ok)
+			require.Equal(t, expected, errObj.Message)
+		}
+	}
+}
+
+func TestSortBuiltin(t *testing.T) {
+	testCases := []struct {
+		input    string
+		expected string
+	}{
+		{"sort([3, 1, 2])", "[1, 2, 3]"},
+		{"sort([2.5, 1, -3])", "[-3, 1, 2.500000]"},
+		{`sort(["pear", "apple", "fig"])`, "[apple, fig, pear]"},
+		{"sort([])", "[]"},
+		{"sort([3, 1, 2], fn(a, b) { b - a })", "[3, 2, 1]"},
+		{`sort(["ccc", "a", "bb"], fn(a, b) { len(a) - len(b) })`, "[a, bb, ccc]"},
+		// the comparator makes any elements sortable and equal ones keep
+		// their order
+		{"sort([[2, 1], [1], [2, 0]], fn(a, b) { a[0] - b[0] })", "[[1], [2, 1], [2, 0]]"},
+		// the input is not mutated
+		{"let a = [3, 1, 2]; sort(a); a", "[3, 1, 2]"},
+		{"let a = [3, 1, 2]; sort(a, fn(a, b) { a - b }); a", "[3, 1, 2]"},
+		{`sort([1, "a"])`, "ERROR[TypeError]: cannot sort integer with string without a comparator"},
+		{`sort(["a", true])`, "ERROR[TypeError]: cannot sort string with boolean without a comparator"},
+		{"sort([[1], [2]])", "ERROR[TypeError]: cannot sort array with array without a comparator"},
+		{"sort(1)", "ERROR[TypeError]: first argument to `sort` must be array, got integer"},
+		{"sort([1], 1)", "ERROR[TypeError]: second argument to `sort` must be function or builtin, got integer"},
+		{"sort([1, 2], fn(a, b) { true })", "ERROR[TypeError]: comparator of `sort` must return integer, got boolean"},
+		{"sort([2, 1], fn(a, b) {})", "ERROR[TypeError]: comparator of `sort` must return integer, got null"},
+		{"sort([1, 2], fn(a, b) { a + foo })", "ERROR[NameError]: identifier not found: foo"},
+		{"sort()", "ERROR: wrong number of arguments. got=0, want=1 or 2"},
+	}
+
+	for _, tc := range testCases {
+		require.Equal(t, tc.expected, testEval(tc.input).Inspect(), tc.input)
+	}
+}
+
+func TestEvalSafe(t *testing.T) {
+	// a prefix expression without an operand can't come out of the parser
+	// and makes Eval dereference a nil object
+	program := &ast.Program{
+		Statements: []ast.Statement{
+			ast.ExpressionStatement{
+				Expression: ast.PrefixExpression{Operator: "-"},
+			},
+		},
+	}
+
+	require.Panics(t, func() { Eval(program, object.NewEnvironment()) })
+
+	evaluated := EvalSafe(program, object.NewEnvironment())
+	errObj, ok := evaluated.(*object.Error)
+	require.True(t, ok)
+	require.True(t, strings.HasPrefix(errObj.Message, "panic during evaluation: "))
+
+	// programs that don't panic are evaluated as usual
+	program = parser.New(lexer.New("1 + 2")).ParseProgram()
+	testIntegerObject(t, EvalSafe(program, object.NewEnvironment()), 3)
+}
+
+func TestEnvironmentSnapshotRestore(t *testing.T) {
+	env := object.NewEnvironment()
+	Eval(parser.New(lexer.New("let a = 1;")).ParseProgram(), env)
+
+	snapshot := env.Snapshot()
+
+	for i := 0; i < 2; i++ {
+		program := parser.New(lexer.New("let a = 10; let b = 2; a + b")).ParseProgram()
+		testIntegerObject(t, Eval(program, env), 12)
+
+		env.Restore(snapshot)
+
+		a, ok := env.Get("a")
+		require.True(t, ok)
+		testIntegerObject(t, a, 1)
+
+		_, ok = env.Get("b")
+		require.False(t, ok)
+	}
+}
+
+func TestAssertBuiltin(t *testing.T) {
+	testCases := []struct {
+		input    string
+		expected interface{}
+	}{
+		{"assert(true)", nil},
+		{"assert(1 < 2, \"math works\")", nil},
+		{"assert([])", nil},
+		{"assert(false)", "assertion failed"},
+		{"assert(if (false) { 1 })", "assertion failed"},
+		{"assert(1 > 2, \"one is not bigger\")", "assertion failed: one is not bigger"},
+		{"assert(false, 1)", "second argument to `assert` must be string, got integer"},
+		{"assert()", "wrong number of arguments. got=0, want=1 or 2"},
+		{"assert(false); 5", "assertion failed"},
+	}
+
+	for _, tc := range testCases {
+		evaluated := testEval(tc.input)
+		switch expected := tc.expected.(type) {
+		case nil:
+			require.Equal(t, NULL, evaluated)
+		case string:
+			errObj, ok := evaluated.(*object.Error)
+			require.True(t, ok)
+			require.Equal(t, expected, errObj.Message)
+		}
+	}
+}
+
+func TestArityBuiltin(t *testing.T) {
+	testCases := []struct {
+		input    string
+		expected interface{}
+	}{
+		{"arity(fn(a, b) {})", 2},
+		{"arity(fn() { 1 })", 0},
+		{"let f = fn(x) { x }; arity(f)", 1},
+		{"arity(len)", "argument to `arity` must be function, got builtin"},
+		{"arity(1)", "argument to `arity` must be function, got integer"},
+		{"arity()", "wrong number of arguments. got=0, want=1"},
+	}
+
+	for _, tc := range testCases {
+		evaluated := testEval(tc.input)
+		switch expected := tc.expected.(type) {
+		case int:
+			testIntegerObject(t, evaluated, int64(expected))
+		case string:
+			errObj, ok := evaluated.(*object.Error)
+			require.True(t, ok)
+			require.Equal(t, expected, errObj.Message)
+		}
+	}
+}
+
+func TestFormatBuiltin(t *testing.T) {
+	testCases := []struct {
+		input    string
+		expected string
+		isError  bool
+	}{
+		{`format("{} + {} = {}", 1, 2, 3)`, "1 + 2 = 3", false},
+		{`format("%s is %d years old", "monkey", 7)`, "monkey is 7 years old", false},
+		{`format("%f", 1.5)`, "1.500000", false},
+		{`format("%f", 2)`, "2.000000", false},
+		{`format("{} and {}", [1, 2], true)`, "[1, 2] and true", false},
+		{`format("100%%")`, "100%", false},
+		{`format("no placeholders")`, "no placeholders", false},
+		{`format("{} {}", 1)`, "wrong number of values for `format`: placeholders=2, got=1", true},
+		{`format("{}", 1, 2)`, "wrong number of values for `format`: placeholders=1, got=2", true},
+		{`format("%d", "a")`, "%d in `format` needs an integer, got string", true},
+		{`format("%f", "a")`, "%f in `format` needs an integer or float, got string", true},
+		{`format("%x", 1)`, "unsupported placeholder %x in `format`", true},
+		{`format(1)`, "first argument to `format` must be string, got integer", true},
+		{`format()`, "wrong number of arguments. got=0, want at least 1", true},
+	}
+
+	for _, tc := range testCases {
+		evaluated := testEval(tc.input)
+		if tc.isError {
+			errObj, ok := evaluated.(*object.Error)
+			require.True(t, ok)
+			require.Equal(t, tc.expected, errObj.Message)
+		} else {
+			str, ok := evaluated.(object.String)
+			require.True(t, ok)
+			require.Equal(t, tc.expected, str.Value)
+		}
+	}
+}
+
+func TestClockBuiltin(t *testing.T) {
+	elapsed := []time.Duration{2 * time.Second, 3500 * time.Millisecond}
+	original := object.Clock
+	defer func() { object.Clock = original }()
+	object.Clock = func() time.Duration {
+		now := elapsed[0]
+		elapsed = elapsed[1:]
+		return now
+	}
+
+	evaluated := testEval("let start = clock(); let end = clock(); [start, end - start]")
+	require.Equal(t, "[2000000000, 1500000000]", evaluated.Inspect())
+
+	errObj, ok := testEval("clock(1)").(*object.Error)
+	require.True(t, ok)
+	require.Equal(t, "wrong number of arguments. got=1, want=0", errObj.Message)
+}
+
+func TestFreezeBuiltin(t *testing.T) {
+	testCases := []struct {
+		input    string
+		expected string
+	}{
+		{"let a = freeze([1, 2, 3]); a[0] + len(a) + last(a)", "7"},
+		{"let a = freeze([1, [2]]); a[1][0] = 3; a", "[1, [3]]"},
+		{"let a = freeze([1, 2, 3]); rest(a)", "[2, 3]"},
+		{"let a = [1, 2]; let b = push(a, 3); freeze(a); b[0] = 9; [a, b]", "[[1, 2], [9, 2, 3]]"},
+		{`let h = freeze({"k": 1}); h["k"]`, "1"},
+		{"let a = freeze([1]); a[0] = 2", "ERROR[TypeError]: cannot assign to frozen array"},
+		{"let a = [1]; freeze(a); a[0] = 2", "ERROR[TypeError]: cannot assign to frozen array"},
+		{"let a = freeze([1]); push(a, 2)", "ERROR: cannot push to frozen array"},
+		{`let h = freeze({}); h["k"] = 1`, "ERROR[TypeError]: cannot assign to frozen hash"},
+		{"freeze(1)", "ERROR: argument to `freeze` must be array or hash, got integer"},
+		{"freeze()", "ERROR: wrong number of arguments. got=0, want=1"},
+	}
+
+	for _, tc := range testCases {
+		require.Equal(t, tc.expected, testEval(tc.input).Inspect(), tc.input)
+	}
+}
+
+func TestApproxEqBuiltin(t *testing.T) {
+	testCases := []struct {
+		input    string
+		expected string
+	}{
+		{"0.1 + 0.2 == 0.3", "false"},
+		{"approxEq(0.1 + 0.2, 0.3, 0.0001)", "true"},
+		{"approxEq(1.0, 1.1, 0.01)", "false"},
+		{"approxEq(2, 2.0, 0)", "true"},
+		{"approxEq(1, 2, 1)", "true"},
+		{`approxEq(1, "1", 0.1)`, "ERROR: arguments to `approxEq` must be integer or float, got string"},
+		{"approxEq(1, 1, -0.5)", "ERROR: tolerance of `approxEq` must not be negative, got -0.500000"},
+		{"approxEq(1, 1)", "ERROR: wrong number of arguments. got=2, want=3"},
+	}
+
+	for _, tc := range testCases {
+		require.Equal(t, tc.expected, testEval(tc.input).Inspect(), tc.input)
+	}
+}
+
+func TestHashLiteralStringKeys(t *testing.T) {
+	input := `let name = "key"; let h = {name: 1}; [h["name"], h["key"]]`
+
+	testCases := []struct {
+		stringKeys bool
+		expected   string
+	}{
+		{false, "[null, 1]"},
+		{true, "[1, null]"},
+	}
+
+	for _, tc := range testCases {
+		p := parser.New(lexer.New(input))
+		p.StringKeys = tc.stringKeys
+		program := p.ParseProgram()
+		require.Empty(t, p.Errors())
+
+		evaluated := Eval(program, object.NewEnvironment())
+		require.Equal(t, tc.expected, evaluated.Inspect())
+	}
+}
+
+func TestMixedNumericOperations(t *testing.T) {
+	testCases := []struct {
+		input    string
+		expected interface{}
+	}{
+		{"3 + 1.5", 4.5},
+		{"1.5 + 3", 4.5},
+		{"1.5 + 1.5", 3.0},
+		{"3 - 0.5", 2.5},
+		{"0.5 - 3", -2.5},
+		{"2 * 1.5", 3.0},
+		{"1.5 * 2", 3.0},
+		{"3 / 2.0", 1.5},
+		{"3.0 / 2", 1.5},
+		{"3 / 2", 1},
+		{"7 / 2", 3},
+		{"7 / 2.0", 3.5},
+		{"-7 / 2", -3},
+		{"1 < 1.5", true},
+		{"1.5 > 1", true},
+		{"2 <= 2.0", true},
+		{"2.0 >= 3", false},
+		{"2 == 2.0", true},
+		{"2.0 != 2", false},
+		{"5 == 5.0", true},
+		{"5 != 5.0", false},
+		{"5 != 4.0", true},
+		{"5 == 5.5", false},
+		{"5.5 == 5", false},
+		{"-0.0 == 0", true},
+	}
+
+	for _, tc := range testCases {
+		evaluated := testEval(tc.input)
+		switch expected := tc.expected.(type) {
+		case int:
+			testIntegerObject(t, evaluated, int64(expected))
+		case float64:
+			testFloatObject(t, evaluated, expected)
+		case bool:
+			testBooleanObject(t, evaluated, expected)
+		}
+	}
+}
+
+func TestWhileLoops(t *testing.T) {
+	testCases := []struct {
+		input    string
+		expected interface{}
+	}{
+		{"let i = 0; while (i < 10) { let i = i + 1; } i", 10},
+		{"let i = 0; while (true) { if (i == 5) { break; } let i = i + 1; } i", 5},
+		{
+			"let i = 0; let sum = 0; while (i < 5) { let i = i + 1; if (i == 3) { continue; } let sum = sum + i; } sum",
+			12,
+		},
+		{"while (false) { 1 }", nil},
+		{"let i = 0; while (i < 3) { let i = i + 1; break; } i", 1},
+		{"let f = fn() { while (true) { return 7; } }; f()", 7},
+		{"let f = fn() { return 2; }; let i = 0; while (i < 3) { let i = i + f(); } i", 4},
+		// break and continue only leave the innermost loop
+		{
+			"let i = 0; let n = 0; while (i < 3) { let i = i + 1; let j = 0; while (true) { let j = j + 1; let n = n + 1; if (j == 2) { break; } } } n",
+			6,
+		},
+		{"break;", "break outside of loop"},
+		{"let f = fn() { continue; }; while (true) { f(); }", "continue outside of loop"},
+		{"while (x) { 1 }", "identifier not found: x"},
+	}
+
+	for _, tc := range testCases {
+		evaluated := testEval(tc.input)
+		switch expected := tc.expected.(type) {
+		case int:
+			testIntegerObject(t, evaluated, int64(expected))
+		case nil:
+			require.Equal(t, NULL, evaluated)
+		case string:
+			errObj, ok := evaluated.(*object.Error)
+			require.True(t, ok)
+			require.Equal(t, expected, errObj.Message)
+		}
+	}
+}
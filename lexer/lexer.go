@@ -2,6 +2,7 @@ package lexer
 
 import (
 	"strings"
+	"unicode/utf8"
 
 	"github.com/stevensopilidis/monkey/token"
 )
@@ -11,19 +12,47 @@ type Lexer struct {
 	position     int  // current position in input
 	readPosition int  // position from which next read will start
 	ch           byte // current char under examination
+	line         int  // line of the current char
 }
 
 // Function for creating a new lexer based on the input source code
 func New(input string) *Lexer {
 	l := &Lexer{
 		input: input,
+		line:  1,
 	}
 	l.readChar()
 	return l
 }
 
+// function for rewinding the lexer to the start of its input
+// so that the same input can be tokenized again
+func (l *Lexer) Reset() {
+	l.position = 0
+	l.readPosition = 0
+	l.ch = 0
+	l.line = 1
+	l.readChar()
+}
+
+// function for reading all the remaining tokens (including EOF)
+func (l *Lexer) Tokens() []token.Token {
+	tokens := []token.Token{}
+	for {
+		tok := l.NextToken()
+		tokens = append(tokens, tok)
+		if tok.Type == token.EOF {
+			return tokens
+		}
+	}
+}
+
 // function for reading the next currect from input
 func (l *Lexer) readChar() {
+	if l.ch == '\n' {
+		l.line++
+	}
+
 	if l.readPosition >= len(l.input) {
 		l.ch = 0
 	} else {
@@ -38,6 +67,7 @@ func (l *Lexer) NextToken() token.Token {
 	var tok token.Token
 
 	l.skipWhiteSpace()
+	line := l.line
 
 	switch l.ch {
 	case '=':
@@ -47,6 +77,12 @@ func (l *Lexer) NextToken() token.Token {
 			l.readChar()
 			literal := string(ch) + string(l.ch)
 			tok = token.Token{Type: token.EQ, Literal: literal}
+		} else if l.peekChar() == '>' {
+			// arrow of an expression bodied function
+			ch := l.ch
+			l.readChar()
+			literal := string(ch) + string(l.ch)
+			tok = token.Token{Type: token.FAT_ARROW, Literal: literal}
 		} else {
 			// assignment operator
 			tok = newToken(token.ASSIGN, l.ch)
@@ -66,15 +102,49 @@ func (l *Lexer) NextToken() token.Token {
 	case '}':
 		tok = newToken(token.RBRACE, l.ch)
 	case '<':
-		tok = newToken(token.LT, l.ch)
+		if l.peekChar() == '=' {
+			// less-or-equal operator
+			ch := l.ch
+			l.readChar()
+			literal := string(ch) + string(l.ch)
+			tok = token.Token{Type: token.LT_EQ, Literal: literal}
+		} else {
+			tok = newToken(token.LT, l.ch)
+		}
 	case '>':
-		tok = newToken(token.GT, l.ch)
+		if l.peekChar() == '=' {
+			// greater-or-equal operator
+			ch := l.ch
+			l.readChar()
+			literal := string(ch) + string(l.ch)
+			tok = token.Token{Type: token.GT_EQ, Literal: literal}
+		} else {
+			tok = newToken(token.GT, l.ch)
+		}
 	case '/':
+		if l.peekChar() == '*' {
+			// only an unterminated block comment gets here
+			tok = token.Token{Type: token.ILLEGAL, Literal: l.input[l.position:], Line: line}
+			for l.ch != 0 {
+				l.readChar()
+			}
+			return tok
+		}
 		tok = newToken(token.SLASH, l.ch)
 	case '*':
 		tok = newToken(token.ASTERISK, l.ch)
 	case '-':
-		tok = newToken(token.MINUS, l.ch)
+		if l.peekChar() == '>' {
+			// arrow of a lambda
+			ch := l.ch
+			l.readChar()
+			literal := string(ch) + string(l.ch)
+			tok = token.Token{Type: token.ARROW, Literal: literal}
+		} else {
+			tok = newToken(token.MINUS, l.ch)
+		}
+	case '\\':
+		tok = newToken(token.BACKSLASH, l.ch)
 	case '!':
 		if l.peekChar() == '=' {
 			// equal operator
@@ -86,9 +156,53 @@ func (l *Lexer) NextToken() token.Token {
 			// assignment operator
 			tok = newToken(token.BANG, l.ch)
 		}
+	case '&':
+		if l.peekChar() == '&' {
+			// logical and operator
+			ch := l.ch
+			l.readChar()
+			literal := string(ch) + string(l.ch)
+			tok = token.Token{Type: token.AND, Literal: literal}
+		} else {
+			tok = newToken(token.ILLEGAL, l.ch)
+		}
+	case '|':
+		if l.peekChar() == '|' {
+			// logical or operator
+			ch := l.ch
+			l.readChar()
+			literal := string(ch) + string(l.ch)
+			tok = token.Token{Type: token.OR, Literal: literal}
+		} else {
+			tok = newToken(token.ILLEGAL, l.ch)
+		}
+	case '?':
+		if l.peekChar() == '?' {
+			// nil-coalescing operator
+			ch := l.ch
+			l.readChar()
+			literal := string(ch) + string(l.ch)
+			tok = token.Token{Type: token.NULLISH, Literal: literal}
+		} else {
+			tok = newToken(token.ILLEGAL, l.ch)
+		}
 	case '"':
-		tok.Type = token.STRING
+		start := l.position
 		tok.Literal = l.readString()
+		tok.Type = token.STRING
+		if l.ch != '"' {
+			// unterminated, the literal is the rest of the input
+			tok.Type = token.ILLEGAL
+			tok.Literal = l.input[start:l.position]
+		}
+	case '`':
+		literal, ok := l.readRawString()
+		if ok {
+			tok.Type = token.STRING
+		} else {
+			tok.Type = token.ILLEGAL
+		}
+		tok.Literal = literal
 	case '[':
 		tok = newToken(token.LBRACKET, l.ch)
 	case ']':
@@ -102,24 +216,43 @@ func (l *Lexer) NextToken() token.Token {
 		if isLetter(l.ch) {
 			tok.Literal = l.readIdentifier()
 			tok.Type = token.LookUpIdent(tok.Literal)
+			tok.Line = line
 			return tok
-		} else if isDigit(l.ch) {
-			num := l.readNumber()
-			parts := strings.Split(num, ".")
-			if len(parts) == 2 {
-				// float
+		} else if isDigit(l.ch) || l.ch == '.' && isDigit(l.peekChar()) {
+			start := l.position
+			tok.Literal = l.readNumber()
+			if tok.Literal == "0" && (l.ch == 'x' || l.ch == 'X') {
+				// there are no hex literals, so 0x and whatever follows it
+				// is reported as a whole
+				for isIdentifierChar(l.ch) {
+					l.readChar()
+				}
+				tok.Type = token.ILLEGAL
+				tok.Literal = l.input[start:l.position]
+			} else if strings.ContainsAny(tok.Literal, ".eE") {
 				tok.Type = token.FLOAT
-				tok.Literal = parts[0] + "." + parts[1]
 			} else {
 				tok.Type = token.INT
-				tok.Literal = parts[0]
 			}
-			l.decrementReadPosition()
+			tok.Line = line
+			return tok
+		} else if l.ch == '.' && l.peekChar() == '.' &&
+			l.readPosition+1 < len(l.input) && l.input[l.readPosition+1] == '.' {
+			// rest pattern of a destructuring let
+			l.readChar()
+			l.readChar()
+			tok = token.Token{Type: token.ELLIPSIS, Literal: "..."}
 		} else {
-			tok = newToken(token.ILLEGAL, l.ch)
+			// the whole character, not just its first byte
+			_, size := utf8.DecodeRuneInString(l.input[l.position:])
+			tok = token.Token{Type: token.ILLEGAL, Literal: l.input[l.position : l.position+size]}
+			for i := 1; i < size; i++ {
+				l.readChar()
+			}
 		}
 	}
 
+	tok.Line = line
 	l.readChar()
 	return tok
 }
@@ -144,45 +277,107 @@ func (l *Lexer) readString() string {
 	return l.input[position:l.position]
 }
 
-// function that determines if character is letter
+// function for parsing a raw string literal delimited by backticks, its
+// content is taken as is, ok is false when the closing backtick is missing
+func (l *Lexer) readRawString() (string, bool) {
+	position := l.readPosition
+	for {
+		l.readChar()
+		if l.ch == '`' {
+			return l.input[position:l.position], true
+		}
+		if l.ch == 0 {
+			return l.input[position-1 : l.position], false
+		}
+	}
+}
+
+// function that determines if character can start an identifier
 func isLetter(ch byte) bool {
-	return 'a' <= ch && ch <= 'z' || 'A' <= ch && ch <= 'Z'
+	return 'a' <= ch && ch <= 'z' || 'A' <= ch && ch <= 'Z' || ch == '_'
+}
+
+// function that determines if character can continue an identifier
+// (digits are allowed after the first character)
+func isIdentifierChar(ch byte) bool {
+	return isLetter(ch) || '0' <= ch && ch <= '9'
 }
 
 // func that determines if character is digit
 func isDigit(ch byte) bool {
-	return '0' <= ch && ch <= '9' || ch == '.'
+	return '0' <= ch && ch <= '9'
 }
 
 // function for reading an identifier
 func (l *Lexer) readIdentifier() string {
 	position := l.position
-	for isLetter(l.ch) {
+	for isIdentifierChar(l.ch) {
 		l.readChar()
 	}
 	return l.input[position:l.position]
 }
 
-// function for reading a number
+// function for reading a number, at most one '.' is consumed so that
+// 1.2.3 doesn't get lexed as a single number, an exponent (e.g 1.5e-3)
+// is only consumed when digits follow it
 func (l *Lexer) readNumber() string {
 	position := l.position
 	for isDigit(l.ch) {
 		l.readChar()
 	}
+	if l.ch == '.' {
+		l.readChar()
+		for isDigit(l.ch) {
+			l.readChar()
+		}
+	}
+	if l.ch == 'e' || l.ch == 'E' {
+		next := l.peekChar()
+		if (next == '+' || next == '-') && l.readPosition+1 < len(l.input) {
+			next = l.input[l.readPosition+1]
+		}
+		if isDigit(next) {
+			l.readChar()
+			if l.ch == '+' || l.ch == '-' {
+				l.readChar()
+			}
+			for isDigit(l.ch) {
+				l.readChar()
+			}
+		}
+	}
 	return l.input[position:l.position]
 }
 
-// function for skipping whitespaces
+// function for skipping whitespace and comments, line comments (// ...)
+// run to the end of the line and block comments (/* ... */) to the closing
+// */. a block comment that is never closed is left for NextToken to report
 func (l *Lexer) skipWhiteSpace() {
-	for l.ch == ' ' || l.ch == '\t' || l.ch == '\n' || l.ch == '\r' {
-		l.readChar()
+	for {
+		switch {
+		case l.ch == ' ' || l.ch == '\t' || l.ch == '\n' || l.ch == '\r':
+			l.readChar()
+		case l.ch == '/' && l.peekChar() == '/':
+			for l.ch != '\n' && l.ch != 0 {
+				l.readChar()
+			}
+		case l.ch == '/' && l.peekChar() == '*':
+			if !strings.Contains(l.input[l.readPosition+1:], "*/") {
+				return
+			}
+			l.readChar()
+			l.readChar()
+			for !(l.ch == '*' && l.peekChar() == '/') {
+				l.readChar()
+			}
+			l.readChar()
+			l.readChar()
+		default:
+			return
+		}
 	}
 }
 
-func (l *Lexer) decrementReadPosition() {
-	l.readPosition -= 1
-}
-
 // function that peeks at the next read char
 func (l *Lexer) peekChar() byte {
 	if l.readPosition >= len(l.input) {
@@ -19,7 +19,7 @@ func TestNextToken(t *testing.T) {
 		};
 		
 		let result = add(five, ten);
-		!-/*5;
+		!-/ *5;
 		5 < 10 > 5;
 		
 		if (5 < 10) {
@@ -30,6 +30,8 @@ func TestNextToken(t *testing.T) {
 		
 		10 == 10;
 		10 != 9;
+		1 <= 2 >= 3;
+		true && false || true;
 		"foobar"
 		"foo bar"
 		[1, 2];
@@ -123,6 +125,18 @@ func TestNextToken(t *testing.T) {
 		{token.NOT_EQ, "!="},
 		{token.INT, "9"},
 		{token.SEMICOLON, ";"},
+		{token.INT, "1"},
+		{token.LT_EQ, "<="},
+		{token.INT, "2"},
+		{token.GT_EQ, ">="},
+		{token.INT, "3"},
+		{token.SEMICOLON, ";"},
+		{token.TRUE, "true"},
+		{token.AND, "&&"},
+		{token.FALSE, "false"},
+		{token.OR, "||"},
+		{token.TRUE, "true"},
+		{token.SEMICOLON, ";"},
 		{token.STRING, "foobar"},
 		{token.STRING, "foo bar"},
 		{token.LBRACKET, "["},
@@ -145,3 +159,296 @@ func TestNextToken(t *testing.T) {
 		require.Equal(t, tc.expectedType, tok.Type)
 	}
 }
+
+func TestIdentifiersWithDigitsAndUnderscores(t *testing.T) {
+	input := `foo_bar x1 _private counter2 1x`
+
+	tests := []struct {
+		expectedType    token.TokenType
+		expectedLiteral string
+	}{
+		{token.IDENT, "foo_bar"},
+		{token.IDENT, "x1"},
+		{token.IDENT, "_private"},
+		{token.IDENT, "counter2"},
+		{token.INT, "1"},
+		{token.IDENT, "x"},
+		{token.EOF, ""},
+	}
+
+	l := New(input)
+	for _, tc := range tests {
+		tok := l.NextToken()
+		require.Equal(t, tc.expectedLiteral, tok.Literal)
+		require.Equal(t, tc.expectedType, tok.Type)
+	}
+}
+
+func TestNumberEdgeCases(t *testing.T) {
+	input := `-.5 --5 1.2.3 . 7.`
+
+	tests := []struct {
+		expectedType    token.TokenType
+		expectedLiteral string
+	}{
+		{token.MINUS, "-"},
+		{token.FLOAT, ".5"},
+		{token.MINUS, "-"},
+		{token.MINUS, "-"},
+		{token.INT, "5"},
+		{token.FLOAT, "1.2"},
+		{token.FLOAT, ".3"},
+		{token.ILLEGAL, "."},
+		{token.FLOAT, "7."},
+		{token.EOF, ""},
+	}
+
+	l := New(input)
+	for _, tc := range tests {
+		tok := l.NextToken()
+		require.Equal(t, tc.expectedLiteral, tok.Literal)
+		require.Equal(t, tc.expectedType, tok.Type)
+	}
+}
+
+func TestScientificNotation(t *testing.T) {
+	input := `1.5e-3 2E10 3e+2 .5e1 4e x1e2 5e-`
+
+	tests := []struct {
+		expectedType    token.TokenType
+		expectedLiteral string
+	}{
+		{token.FLOAT, "1.5e-3"},
+		{token.FLOAT, "2E10"},
+		{token.FLOAT, "3e+2"},
+		{token.FLOAT, ".5e1"},
+		{token.INT, "4"},
+		{token.IDENT, "e"},
+		{token.IDENT, "x1e2"},
+		{token.INT, "5"},
+		{token.IDENT, "e"},
+		{token.MINUS, "-"},
+		{token.EOF, ""},
+	}
+
+	l := New(input)
+	for _, tc := range tests {
+		tok := l.NextToken()
+		require.Equal(t, tc.expectedLiteral, tok.Literal)
+		require.Equal(t, tc.expectedType, tok.Type)
+	}
+}
+
+func TestRawStrings(t *testing.T) {
+	input := "`a\\nb` `line\none` `` `unterminated"
+
+	tests := []struct {
+		expectedType    token.TokenType
+		expectedLiteral string
+	}{
+		{token.STRING, `a\nb`},
+		{token.STRING, "line\none"},
+		{token.STRING, ""},
+		{token.ILLEGAL, "`unterminated"},
+		{token.EOF, ""},
+	}
+
+	l := New(input)
+	for _, tc := range tests {
+		tok := l.NextToken()
+		require.Equal(t, tc.expectedLiteral, tok.Literal)
+		require.Equal(t, tc.expectedType, tok.Type)
+	}
+}
+
+func TestIllegalTokens(t *testing.T) {
+	input := "0xZZ 0X1F 0x @ é `raw \"unterminated"
+
+	tests := []struct {
+		expectedType    token.TokenType
+		expectedLiteral string
+	}{
+		{token.ILLEGAL, "0xZZ"},
+		{token.ILLEGAL, "0X1F"},
+		{token.ILLEGAL, "0x"},
+		{token.ILLEGAL, "@"},
+		{token.ILLEGAL, "é"},
+		{token.ILLEGAL, "`raw \"unterminated"},
+		{token.EOF, ""},
+	}
+
+	l := New(input)
+	for _, tc := range tests {
+		tok := l.NextToken()
+		require.Equal(t, tc.expectedLiteral, tok.Literal)
+		require.Equal(t, tc.expectedType, tok.Type)
+	}
+
+	tok := New(`"unterminated`).NextToken()
+	require.Equal(t, token.TokenType(token.ILLEGAL), tok.Type)
+	require.Equal(t, `"unterminated`, tok.Literal)
+}
+
+func TestNullishToken(t *testing.T) {
+	input := `a ?? b ? c`
+
+	tests := []struct {
+		expectedType    token.TokenType
+		expectedLiteral string
+	}{
+		{token.IDENT, "a"},
+		{token.NULLISH, "??"},
+		{token.IDENT, "b"},
+		{token.ILLEGAL, "?"},
+		{token.IDENT, "c"},
+		{token.EOF, ""},
+	}
+
+	l := New(input)
+	for _, tc := range tests {
+		tok := l.NextToken()
+		require.Equal(t, tc.expectedLiteral, tok.Literal)
+		require.Equal(t, tc.expectedType, tok.Type)
+	}
+}
+
+func TestEllipsis(t *testing.T) {
+	input := "[a, ...rest] .. .5"
+
+	tests := []struct {
+		expectedType    token.TokenType
+		expectedLiteral string
+	}{
+		{token.LBRACKET, "["},
+		{token.IDENT, "a"},
+		{token.COMMA, ","},
+		{token.ELLIPSIS, "..."},
+		{token.IDENT, "rest"},
+		{token.RBRACKET, "]"},
+		{token.ILLEGAL, "."},
+		{token.ILLEGAL, "."},
+		{token.FLOAT, ".5"},
+		{token.EOF, ""},
+	}
+
+	l := New(input)
+	for _, tc := range tests {
+		tok := l.NextToken()
+		require.Equal(t, tc.expectedLiteral, tok.Literal)
+		require.Equal(t, tc.expectedType, tok.Type)
+	}
+}
+
+func TestFatArrowTokens(t *testing.T) {
+	input := `fn(x) => x == 1`
+
+	tests := []struct {
+		expectedType    token.TokenType
+		expectedLiteral string
+	}{
+		{token.FUNCTION, "fn"},
+		{token.LPAREN, "("},
+		{token.IDENT, "x"},
+		{token.RPAREN, ")"},
+		{token.FAT_ARROW, "=>"},
+		{token.IDENT, "x"},
+		{token.EQ, "=="},
+		{token.INT, "1"},
+		{token.EOF, ""},
+	}
+
+	l := New(input)
+	for _, tc := range tests {
+		tok := l.NextToken()
+		require.Equal(t, tc.expectedLiteral, tok.Literal)
+		require.Equal(t, tc.expectedType, tok.Type)
+	}
+}
+
+func TestLambdaTokens(t *testing.T) {
+	input := `\(x) -> x - 1`
+
+	tests := []struct {
+		expectedType    token.TokenType
+		expectedLiteral string
+	}{
+		{token.BACKSLASH, "\\"},
+		{token.LPAREN, "("},
+		{token.IDENT, "x"},
+		{token.RPAREN, ")"},
+		{token.ARROW, "->"},
+		{token.IDENT, "x"},
+		{token.MINUS, "-"},
+		{token.INT, "1"},
+		{token.EOF, ""},
+	}
+
+	l := New(input)
+	for _, tc := range tests {
+		tok := l.NextToken()
+		require.Equal(t, tc.expectedLiteral, tok.Literal)
+		require.Equal(t, tc.expectedType, tok.Type)
+	}
+}
+
+func TestTokenLines(t *testing.T) {
+	input := `let x = 5;
+let y = "a
+b";
+
+x`
+
+	expectedLines := []int{1, 1, 1, 1, 1, 2, 2, 2, 2, 3, 5, 5}
+
+	l := New(input)
+	for _, line := range expectedLines {
+		tok := l.NextToken()
+		require.Equal(t, line, tok.Line)
+	}
+}
+
+func TestComments(t *testing.T) {
+	input := `// leading comment
+let x = /* inline */ 5; // trailing
+/* spans
+lines */ x /**/ /*/ unterminated`
+
+	tests := []struct {
+		expectedType    token.TokenType
+		expectedLiteral string
+		expectedLine    int
+	}{
+		{token.LET, "let", 2},
+		{token.IDENT, "x", 2},
+		{token.ASSIGN, "=", 2},
+		{token.INT, "5", 2},
+		{token.SEMICOLON, ";", 2},
+		{token.IDENT, "x", 4},
+		{token.ILLEGAL, "/*/ unterminated", 4},
+		{token.EOF, "", 4},
+	}
+
+	l := New(input)
+	for _, tc := range tests {
+		tok := l.NextToken()
+		require.Equal(t, tc.expectedLiteral, tok.Literal)
+		require.Equal(t, tc.expectedType, tok.Type)
+		require.Equal(t, tc.expectedLine, tok.Line)
+	}
+}
+
+func TestReset(t *testing.T) {
+	input := `let add = fn(x, y) {
+	x + y;
+};`
+
+	l := New(input)
+	first := l.Tokens()
+
+	l.Reset()
+	second := l.Tokens()
+
+	require.Equal(t, first, second)
+	require.Equal(t, token.TokenType(token.LET), second[0].Type)
+	require.Equal(t, token.TokenType(token.EOF), second[len(second)-1].Type)
+}
@@ -1,6 +1,27 @@
 package object
 
-import "fmt"
+import (
+	"fmt"
+	"math"
+	"strings"
+	"time"
+)
+
+// global instances of true, false and null shared by
+// both the evaluator and the vm
+var (
+	TRUE  = &Boolean{Value: true}
+	FALSE = &Boolean{Value: false}
+	NULL  = &Null{}
+)
+
+var start = time.Now()
+
+// time source of the clock builtin, it can be replaced to make scripts
+// that measure time deterministic (e.g in tests)
+var Clock = func() time.Duration {
+	return time.Since(start)
+}
 
 var Builtins = []struct {
 	Name    string
@@ -13,15 +34,11 @@ var Builtins = []struct {
 				return newError("wrong number of arguments. got=%d, want=1",
 					len(args))
 			}
-			switch arg := args[0].(type) {
-			case *Array:
-				return &Integer{Value: int64(len(arg.Elements))}
-			case String:
-				return &Integer{Value: int64(len(arg.Value))}
-			default:
-				return newError("argument to `len` not supported, got %s",
-					args[0].Type())
+			if sized, ok := args[0].(Sized); ok {
+				return &Integer{Value: int64(sized.Length())}
 			}
+			return newError("argument to `len` not supported, got %s",
+				args[0].Type().Display())
 		},
 		},
 	},
@@ -43,8 +60,8 @@ var Builtins = []struct {
 					len(args))
 			}
 			if args[0].Type() != ARRAY_OBJ {
-				return newError("argument to `first` must be ARRAY, got %s",
-					args[0].Type())
+				return newError("argument to `first` must be array, got %s",
+					args[0].Type().Display())
 			}
 			arr := args[0].(*Array)
 			if len(arr.Elements) > 0 {
@@ -62,8 +79,8 @@ var Builtins = []struct {
 					len(args))
 			}
 			if args[0].Type() != ARRAY_OBJ {
-				return newError("argument to `last` must be ARRAY, got %s",
-					args[0].Type())
+				return newError("argument to `last` must be array, got %s",
+					args[0].Type().Display())
 			}
 			arr := args[0].(*Array)
 			length := len(arr.Elements)
@@ -82,15 +99,12 @@ var Builtins = []struct {
 					len(args))
 			}
 			if args[0].Type() != ARRAY_OBJ {
-				return newError("argument to `rest` must be ARRAY, got %s",
-					args[0].Type())
+				return newError("argument to `rest` must be array, got %s",
+					args[0].Type().Display())
 			}
 			arr := args[0].(*Array)
-			length := len(arr.Elements)
-			if length > 0 {
-				newElements := make([]Object, length-1)
-				copy(newElements, arr.Elements[1:length])
-				return &Array{Elements: newElements}
+			if len(arr.Elements) > 0 {
+				return arr.Slice(1, len(arr.Elements))
 			}
 			return nil
 		},
@@ -104,18 +118,369 @@ var Builtins = []struct {
 					len(args))
 			}
 			if args[0].Type() != ARRAY_OBJ {
-				return newError("argument to `push` must be ARRAY, got %s",
-					args[0].Type())
+				return newError("argument to `push` must be array, got %s",
+					args[0].Type().Display())
 			}
 			arr := args[0].(*Array)
-			length := len(arr.Elements)
-			newElements := make([]Object, length+1)
-			copy(newElements, arr.Elements)
-			newElements[length] = args[1]
-			return &Array{Elements: newElements}
+			if arr.Frozen {
+				return newError("cannot push to frozen array")
+			}
+			return arr.With(args[1])
+		},
+		},
+	},
+	{
+		"contains",
+		&Builtin{Fn: func(args ...Object) Object {
+			if len(args) != 2 {
+				return newError("wrong number of arguments. got=%d, want=2",
+					len(args))
+			}
+			switch collection := args[0].(type) {
+			case *Array:
+				for _, el := range collection.Elements {
+					if Equals(el, args[1]) {
+						return TRUE
+					}
+				}
+				return FALSE
+			case *Hash:
+				key, ok := args[1].(Hashable)
+				if !ok {
+					return newError("unusable as hash key: %s", args[1].Type().Display())
+				}
+				_, ok = collection.Get(key)
+				return nativeBoolToBooleanObject(ok)
+			case String, *String:
+				haystack, _ := stringValue(collection)
+				needle, ok := stringValue(args[1])
+				if !ok {
+					return newError("second argument to `contains` must be string, got %s",
+						args[1].Type().Display())
+				}
+				return nativeBoolToBooleanObject(strings.Contains(haystack, needle))
+			default:
+				return newError("argument to `contains` not supported, got %s",
+					args[0].Type().Display())
+			}
+		},
+		},
+	},
+	{
+		"abs",
+		&Builtin{Fn: func(args ...Object) Object {
+			if len(args) != 1 {
+				return newError("wrong number of arguments. got=%d, want=1",
+					len(args))
+			}
+			switch arg := args[0].(type) {
+			case *Integer:
+				if arg.Value < 0 {
+					return &Integer{Value: -arg.Value}
+				}
+				return arg
+			case *Float:
+				return &Float{Value: math.Abs(arg.Value)}
+			default:
+				return newError("argument to `abs` must be integer or float, got %s",
+					args[0].Type().Display())
+			}
+		},
+		},
+	},
+	{
+		"min",
+		&Builtin{Fn: func(args ...Object) Object {
+			return extremum("min", args, func(a, b float64) bool { return a < b })
+		},
+		},
+	},
+	{
+		"max",
+		&Builtin{Fn: func(args ...Object) Object {
+			return extremum("max", args, func(a, b float64) bool { return a > b })
+		},
+		},
+	},
+	{
+		"range",
+		&Builtin{Fn: func(args ...Object) Object {
+			if len(args) < 1 || len(args) > 3 {
+				return newError("wrong number of arguments. got=%d, want=1, 2 or 3",
+					len(args))
+			}
+
+			bounds := make([]int64, len(args))
+			for i, arg := range args {
+				integer, ok := arg.(*Integer)
+				if !ok {
+					return newError("argument to `range` must be integer, got %s",
+						arg.Type().Display())
+				}
+				bounds[i] = integer.Value
+			}
+
+			var start, end, step int64 = 0, 0, 1
+			switch len(bounds) {
+			case 1:
+				end = bounds[0]
+			case 2:
+				start, end = bounds[0], bounds[1]
+			case 3:
+				start, end, step = bounds[0], bounds[1], bounds[2]
+			}
+
+			if step == 0 {
+				return newError("step of `range` must not be zero")
+			}
+			if (step > 0 && start > end) || (step < 0 && start < end) {
+				return newError("end of `range` is unreachable: start=%d, end=%d, step=%d",
+					start, end, step)
+			}
+
+			elements := []Object{}
+			for i := start; (step > 0 && i < end) || (step < 0 && i > end); i += step {
+				elements = append(elements, &Integer{Value: i})
+			}
+			return &Array{Elements: elements}
 		},
 		},
 	},
+	{
+		"assert",
+		&Builtin{Fn: func(args ...Object) Object {
+			if len(args) < 1 || len(args) > 2 {
+				return newError("wrong number of arguments. got=%d, want=1 or 2",
+					len(args))
+			}
+			if IsTruthy(args[0]) {
+				return NULL
+			}
+			if len(args) == 1 {
+				return newError("assertion failed")
+			}
+
+			message, ok := stringValue(args[1])
+			if !ok {
+				return newError("second argument to `assert` must be string, got %s",
+					args[1].Type().Display())
+			}
+			return newError("assertion failed: %s", message)
+		},
+		},
+	},
+	{
+		"arity",
+		&Builtin{Fn: func(args ...Object) Object {
+			if len(args) != 1 {
+				return newError("wrong number of arguments. got=%d, want=1",
+					len(args))
+			}
+			switch fn := args[0].(type) {
+			case Function:
+				return &Integer{Value: int64(len(fn.Parameters))}
+			case *CompiledFunction:
+				return &Integer{Value: int64(fn.NumParameters)}
+			case *Closure:
+				return &Integer{Value: int64(fn.Fn.NumParameters)}
+			default:
+				return newError("argument to `arity` must be function, got %s",
+					args[0].Type().Display())
+			}
+		},
+		},
+	},
+	{
+		"format",
+		&Builtin{Fn: func(args ...Object) Object {
+			if len(args) == 0 {
+				return newError("wrong number of arguments. got=0, want at least 1")
+			}
+			template, ok := stringValue(args[0])
+			if !ok {
+				return newError("first argument to `format` must be string, got %s",
+					args[0].Type().Display())
+			}
+
+			formatted, err := formatString(template, args[1:])
+			if err != nil {
+				return err
+			}
+			// answer with the same representation of String as the
+			// format string so it works in both the evaluator and the vm
+			if _, ok := args[0].(*String); ok {
+				return &String{Value: formatted}
+			}
+			return String{Value: formatted}
+		},
+		},
+	},
+	{
+		// nanoseconds elapsed since the program started, the difference of
+		// two calls measures how long the code between them took
+		"clock",
+		&Builtin{Fn: func(args ...Object) Object {
+			if len(args) != 0 {
+				return newError("wrong number of arguments. got=%d, want=0",
+					len(args))
+			}
+			return &Integer{Value: int64(Clock())}
+		},
+		},
+	},
+	{
+		// marks an array or hash as immutable and returns it, nested
+		// arrays and hashes are left as they are
+		"freeze",
+		&Builtin{Fn: func(args ...Object) Object {
+			if len(args) != 1 {
+				return newError("wrong number of arguments. got=%d, want=1",
+					len(args))
+			}
+			switch collection := args[0].(type) {
+			case *Array:
+				collection.Frozen = true
+			case *Hash:
+				collection.Frozen = true
+			default:
+				return newError("argument to `freeze` must be array or hash, got %s",
+					args[0].Type().Display())
+			}
+			return args[0]
+		},
+		},
+	},
+	{
+		// compares two numbers with a tolerance since == on floats is exact
+		// e.g approxEq(0.1 + 0.2, 0.3, 0.0001)
+		"approxEq",
+		&Builtin{Fn: func(args ...Object) Object {
+			if len(args) != 3 {
+				return newError("wrong number of arguments. got=%d, want=3",
+					len(args))
+			}
+			numbers := make([]float64, len(args))
+			for i, arg := range args {
+				number, ok := ToFloat(arg)
+				if !ok {
+					return newError("arguments to `approxEq` must be integer or float, got %s",
+						arg.Type().Display())
+				}
+				numbers[i] = number
+			}
+			if numbers[2] < 0 {
+				return newError("tolerance of `approxEq` must not be negative, got %s",
+					args[2].Inspect())
+			}
+			return nativeBoolToBooleanObject(ApproxEqual(numbers[0], numbers[1], numbers[2]))
+		},
+		},
+	},
+}
+
+// function for finding the argument that wins every comparison against
+// the rest, the result is promoted to float if any argument is a float
+func extremum(name string, args []Object, better func(a, b float64) bool) Object {
+	if len(args) == 0 {
+		return newError("wrong number of arguments. got=0, want at least 1")
+	}
+
+	promote := false
+	values := make([]float64, len(args))
+	for i, arg := range args {
+		switch arg := arg.(type) {
+		case *Integer:
+			values[i] = float64(arg.Value)
+		case *Float:
+			values[i] = arg.Value
+			promote = true
+		default:
+			return newError("argument to `%s` must be integer or float, got %s",
+				name, arg.Type().Display())
+		}
+	}
+
+	best := 0
+	for i := 1; i < len(values); i++ {
+		if better(values[i], values[best]) {
+			best = i
+		}
+	}
+
+	if promote {
+		return &Float{Value: values[best]}
+	}
+	return args[best]
+}
+
+// function for replacing the placeholders of template with values in
+// order, {} and %s insert any value, %d an integer and %f a number while
+// %% is a literal percent sign
+func formatString(template string, values []Object) (string, *Error) {
+	var out strings.Builder
+	placeholders := 0
+
+	for i := 0; i < len(template); i++ {
+		var verb byte
+		switch {
+		case strings.HasPrefix(template[i:], "{}"):
+			verb = 's'
+		case template[i] == '%' && i+1 < len(template):
+			verb = template[i+1]
+		default:
+			out.WriteByte(template[i])
+			continue
+		}
+		i++
+
+		switch verb {
+		case '%':
+			out.WriteByte('%')
+			continue
+		case 's', 'd', 'f':
+		default:
+			return "", newError("unsupported placeholder %%%c in `format`", verb)
+		}
+
+		// keep counting once the values run out so the error can
+		// report how many were needed
+		placeholders++
+		if placeholders > len(values) {
+			continue
+		}
+
+		value := values[placeholders-1]
+		switch verb {
+		case 'd':
+			integer, ok := value.(*Integer)
+			if !ok {
+				return "", newError("%%d in `format` needs an integer, got %s", value.Type().Display())
+			}
+			out.WriteString(fmt.Sprintf("%d", integer.Value))
+		case 'f':
+			number, ok := ToFloat(value)
+			if !ok {
+				return "", newError("%%f in `format` needs an integer or float, got %s", value.Type().Display())
+			}
+			out.WriteString(fmt.Sprintf("%f", number))
+		default:
+			out.WriteString(value.Inspect())
+		}
+	}
+
+	if placeholders != len(values) {
+		return "", newError("wrong number of values for `format`: placeholders=%d, got=%d",
+			placeholders, len(values))
+	}
+	return out.String(), nil
+}
+
+// function that maps a native bool to one of the global boolean instances
+func nativeBoolToBooleanObject(input bool) *Boolean {
+	if input {
+		return TRUE
+	}
+	return FALSE
 }
 
 func newError(format string, a ...interface{}) *Error {
@@ -4,7 +4,11 @@ import (
 	"bytes"
 	"fmt"
 	"hash/fnv"
+	"math"
+	"strconv"
 	"strings"
+	"sync"
+	"unicode/utf8"
 
 	"github.com/stevensopilidis/monkey/ast"
 	"github.com/stevensopilidis/monkey/code"
@@ -13,25 +17,64 @@ import (
 type ObjectType string
 
 const (
-	INTEGER_OBJ              = "INTEGER"
-	BOOLEAN_OBJ              = "BOOLEAN"
-	FLOAT_OBJ                = "FLOAT"
-	NULL_OBJ                 = "NULL"
-	RETURN_VALUE_OBJ         = "RETURN_VALUE"
-	ERROR_OBJ                = "ERROR"
-	FUNCTION_OBJ             = "FUNCTION"
-	STRING_OBJ               = "STRING"
-	Builtin_OBJ              = "Builtin"
-	ARRAY_OBJ                = "ARRAY"
-	HASH_OBJ                 = "HASH"
-	COMPILED_FUNCTION_OBJECT = "COMPILED_FUNCTION"
+	INTEGER_OBJ              ObjectType = "INTEGER"
+	BOOLEAN_OBJ              ObjectType = "BOOLEAN"
+	FLOAT_OBJ                ObjectType = "FLOAT"
+	NULL_OBJ                 ObjectType = "NULL"
+	RETURN_VALUE_OBJ         ObjectType = "RETURN_VALUE"
+	ERROR_OBJ                ObjectType = "ERROR"
+	FUNCTION_OBJ             ObjectType = "FUNCTION"
+	STRING_OBJ               ObjectType = "STRING"
+	Builtin_OBJ              ObjectType = "Builtin"
+	ARRAY_OBJ                ObjectType = "ARRAY"
+	HASH_OBJ                 ObjectType = "HASH"
+	COMPILED_FUNCTION_OBJECT ObjectType = "COMPILED_FUNCTION"
+	CLOSURE_OBJ              ObjectType = "CLOSURE"
+	BREAK_OBJ                ObjectType = "BREAK"
+	CONTINUE_OBJ             ObjectType = "CONTINUE"
 )
 
+// function that returns every type an object can have
+func AllObjectTypes() []ObjectType {
+	return []ObjectType{
+		INTEGER_OBJ, BOOLEAN_OBJ, FLOAT_OBJ, NULL_OBJ, RETURN_VALUE_OBJ,
+		ERROR_OBJ, FUNCTION_OBJ, STRING_OBJ, Builtin_OBJ, ARRAY_OBJ,
+		HASH_OBJ, COMPILED_FUNCTION_OBJECT, CLOSURE_OBJ, BREAK_OBJ, CONTINUE_OBJ,
+	}
+}
+
+// function that determines if t is one of the known object types
+func (t ObjectType) IsValid() bool {
+	for _, known := range AllObjectTypes() {
+		if t == known {
+			return true
+		}
+	}
+	return false
+}
+
+// function that returns the name of t as shown to users in error
+// messages, the raw ObjectType is kept for comparisons
+func (t ObjectType) Display() string {
+	switch t {
+	case RETURN_VALUE_OBJ:
+		return "return value"
+	case Builtin_OBJ:
+		return "builtin"
+	case COMPILED_FUNCTION_OBJECT, CLOSURE_OBJ:
+		return "function"
+	}
+	return strings.ToLower(string(t))
+}
+
 // environment will keep track of the values of the identifiers
 type Environment struct {
 	store map[string]Object
 	// env that current env is enclosed by
 	outer *Environment
+	// guards the store of environments shared across goroutines, nil for
+	// the default unsynchronized environment
+	mu *sync.RWMutex
 }
 
 func NewEnclosedEnvironment(outer *Environment) *Environment {
@@ -45,8 +88,23 @@ func NewEnvironment() *Environment {
 	return &Environment{store: s, outer: nil}
 }
 
+// function that returns an environment that is safe to share across
+// goroutines, environments enclosed by it (e.g. of function calls) are
+// local to the goroutine that created them and stay unsynchronized
+func NewSyncEnvironment() *Environment {
+	env := NewEnvironment()
+	env.mu = &sync.RWMutex{}
+	return env
+}
+
 func (e *Environment) Get(name string) (Object, bool) {
+	if e.mu != nil {
+		e.mu.RLock()
+	}
 	obj, ok := e.store[name]
+	if e.mu != nil {
+		e.mu.RUnlock()
+	}
 	if !ok && e.outer != nil {
 		// check if identifier exists in outside environment
 		obj, ok = e.outer.Get(name)
@@ -55,16 +113,54 @@ func (e *Environment) Get(name string) (Object, bool) {
 }
 
 func (e *Environment) Set(name string, val Object) Object {
+	if e.mu != nil {
+		e.mu.Lock()
+		defer e.mu.Unlock()
+	}
 	e.store[name] = val
 	return val
 }
 
+// function that returns a shallow copy of the bindings of the environment
+// (outer environments are not included)
+func (e *Environment) Snapshot() map[string]Object {
+	if e.mu != nil {
+		e.mu.RLock()
+		defer e.mu.RUnlock()
+	}
+	return copyStore(e.store)
+}
+
+// function for replacing the bindings of the environment with a snapshot,
+// the snapshot is copied so it can be restored more than once
+func (e *Environment) Restore(snapshot map[string]Object) {
+	store := copyStore(snapshot)
+	if e.mu != nil {
+		e.mu.Lock()
+		defer e.mu.Unlock()
+	}
+	e.store = store
+}
+
+func copyStore(store map[string]Object) map[string]Object {
+	copied := make(map[string]Object, len(store))
+	for name, obj := range store {
+		copied[name] = obj
+	}
+	return copied
+}
+
 // struct that will be used to index internal hash maps
 type HashKey struct {
 	Type  ObjectType
 	Value uint64
 }
 
+// interface implemented by collections whose size is given by len
+type Sized interface {
+	Length() int
+}
+
 // interface that needs to be implemented by structs that are Hashable
 type Hashable interface {
 	HashKey() HashKey
@@ -92,6 +188,172 @@ func (s String) HashKey() HashKey {
 	return HashKey{Type: s.Type(), Value: h.Sum64()}
 }
 
+// function for extracting the native value out of both
+// representations of a String (value and pointer)
+func stringValue(obj Object) (string, bool) {
+	switch obj := obj.(type) {
+	case String:
+		return obj.Value, true
+	case *String:
+		return obj.Value, true
+	default:
+		return "", false
+	}
+}
+
+// function that returns the character at index of a string, strings are
+// indexed by runes like `len` measures them. ok is false when index is out
+// of range
+func CharAt(value string, index int64) (string, bool) {
+	if index < 0 {
+		return "", false
+	}
+	i := int64(0)
+	for _, r := range value {
+		if i == index {
+			return string(r), true
+		}
+		i++
+	}
+	return "", false
+}
+
+// function that determines if obj is an integer or a float
+func Numeric(obj Object) bool {
+	_, ok := ToFloat(obj)
+	return ok
+}
+
+// function for promoting an integer or a float to a float64, ok is false
+// for any other object
+func ToFloat(obj Object) (float64, bool) {
+	switch obj := obj.(type) {
+	case *Integer:
+		return float64(obj.Value), true
+	case *Float:
+		return obj.Value, true
+	default:
+		return 0, false
+	}
+}
+
+// function that determines if a and b are at most eps apart, == on floats
+// stays exact (0.1 + 0.2 == 0.3 is false) so this is the way to compare
+// results of float arithmetic
+func ApproxEqual(a, b, eps float64) bool {
+	return math.Abs(a-b) <= eps
+}
+
+// function for applying an arithmetic operator (+, -, *, /) to two numbers
+// with the rules shared by the evaluator and the vm: integers stay integers
+// and their division truncates, a float on either side promotes both
+// operands and the result to floats
+func Arithmetic(operator string, left, right Object) (Object, error) {
+	leftInt, leftOk := left.(*Integer)
+	rightInt, rightOk := right.(*Integer)
+	if leftOk && rightOk {
+		l, r := leftInt.Value, rightInt.Value
+		switch operator {
+		case "+":
+			return &Integer{Value: l + r}, nil
+		case "-":
+			return &Integer{Value: l - r}, nil
+		case "*":
+			return &Integer{Value: l * r}, nil
+		case "/":
+			if r == 0 {
+				return nil, fmt.Errorf("division by zero: %d / %d", l, r)
+			}
+			return &Integer{Value: l / r}, nil
+		}
+	} else if l, ok := ToFloat(left); ok {
+		if r, ok := ToFloat(right); ok {
+			switch operator {
+			case "+":
+				return &Float{Value: l + r}, nil
+			case "-":
+				return &Float{Value: l - r}, nil
+			case "*":
+				return &Float{Value: l * r}, nil
+			case "/":
+				return &Float{Value: l / r}, nil
+			}
+		}
+	}
+
+	return nil, fmt.Errorf("unsupported arithmetic: %s %s %s",
+		left.Type().Display(), operator, right.Type().Display())
+}
+
+// function that determines if obj counts as true in a condition, shared
+// by the evaluator and the vm so both agree on it. only false and null
+// are falsy and they are matched by type, not by identity with the
+// global instances
+func IsTruthy(obj Object) bool {
+	switch obj := obj.(type) {
+	case *Boolean:
+		return obj.Value
+	case *Null:
+		return false
+	default:
+		return true
+	}
+}
+
+// function that determines if two objects are equal by value
+// arrays and hashes are compared element by element
+func Equals(a, b Object) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+
+	if a.Type() != b.Type() {
+		return false
+	}
+
+	switch a.Type() {
+	case INTEGER_OBJ:
+		return a.(*Integer).Value == b.(*Integer).Value
+	case FLOAT_OBJ:
+		return a.(*Float).Value == b.(*Float).Value
+	case BOOLEAN_OBJ:
+		return a.(*Boolean).Value == b.(*Boolean).Value
+	case NULL_OBJ:
+		return true
+	case STRING_OBJ:
+		left, _ := stringValue(a)
+		right, _ := stringValue(b)
+		return left == right
+	case ARRAY_OBJ:
+		left := a.(*Array).Elements
+		right := b.(*Array).Elements
+		if len(left) != len(right) {
+			return false
+		}
+		for i := range left {
+			if !Equals(left[i], right[i]) {
+				return false
+			}
+		}
+		return true
+	case HASH_OBJ:
+		left := a.(*Hash).Pairs
+		right := b.(*Hash).Pairs
+		if len(left) != len(right) {
+			return false
+		}
+		for key, pair := range left {
+			other, ok := right[key]
+			if !ok || !Equals(pair.Value, other.Value) {
+				return false
+			}
+		}
+		return true
+	default:
+		return a == b
+	}
+}
+
 type HashPair struct {
 	Key   Object
 	Value Object
@@ -99,7 +361,58 @@ type HashPair struct {
 
 // struct representing hash_map
 type Hash struct {
-	Pairs map[HashKey]HashPair
+	Pairs  map[HashKey]HashPair
+	Frozen bool // set by the freeze builtin, frozen hashes reject mutation
+}
+
+// function that returns the number of pairs of the hash
+func (h *Hash) Length() int {
+	return len(h.Pairs)
+}
+
+// function for finding the slot of a key in the hash
+// two different keys can produce the same HashKey, so the stored key is
+// compared and on a collision the next slot is probed
+func (h *Hash) slot(key Hashable) (HashKey, bool) {
+	hashKey := key.HashKey()
+	for {
+		pair, ok := h.Pairs[hashKey]
+		if !ok {
+			return hashKey, false
+		}
+		if Equals(pair.Key, key.(Object)) {
+			return hashKey, true
+		}
+		hashKey.Value++
+	}
+}
+
+// function for getting the pair stored under key
+func (h *Hash) Get(key Hashable) (HashPair, bool) {
+	hashKey, ok := h.slot(key)
+	if !ok {
+		return HashPair{}, false
+	}
+	return h.Pairs[hashKey], true
+}
+
+// function for storing value under key (overwriting a previous value)
+func (h *Hash) Set(key Hashable, value Object) {
+	if h.Pairs == nil {
+		h.Pairs = make(map[HashKey]HashPair)
+	}
+	hashKey, _ := h.slot(key)
+	h.Pairs[hashKey] = HashPair{Key: key.(Object), Value: value}
+}
+
+// function that returns a copy of the hash, nested arrays and hashes are
+// copied too so mutating the clone never affects the original
+func (h *Hash) Clone() *Hash {
+	pairs := make(map[HashKey]HashPair, len(h.Pairs))
+	for hashKey, pair := range h.Pairs {
+		pairs[hashKey] = HashPair{Key: pair.Key, Value: clone(pair.Value)}
+	}
+	return &Hash{Pairs: pairs}
 }
 
 func (h Hash) Type() ObjectType {
@@ -121,9 +434,108 @@ func (h Hash) Inspect() string {
 	return out.String()
 }
 
-// struct representing array
+func (h Hash) ReplString() string {
+	var out bytes.Buffer
+
+	pairs := []string{}
+	for _, pair := range h.Pairs {
+		pairs = append(pairs, fmt.Sprintf("%s: %s",
+			ReplString(pair.Key), ReplString(pair.Value)))
+	}
+
+	out.WriteString("{")
+	out.WriteString(strings.Join(pairs, ", "))
+	out.WriteString("}")
+	return out.String()
+}
+
+// struct representing array, arrays built by With and Slice share the
+// backing array of the array they are built from instead of copying it
+// so Elements must only be written through Set
 type Array struct {
 	Elements []Object
+	Frozen   bool     // set by the freeze builtin, frozen arrays reject mutation
+	shared   *backing // nil while the backing array is owned by this array alone
+}
+
+// bookkeeping of a backing array shared by several arrays
+type backing struct {
+	// number of elements of the backing array in use, only an array that
+	// ends there can append in place without overwriting another's elements
+	used int
+}
+
+// function that returns the number of elements of the array
+func (arr *Array) Length() int {
+	return len(arr.Elements)
+}
+
+// function that returns a new array with elements appended, the backing
+// array is shared when it has room to spare so a chain of With calls
+// (e.g repeated pushes) only copies now and then. nested arrays and hashes
+// are shared, not copied
+func (arr *Array) With(elements ...Object) *Array {
+	n := len(arr.Elements)
+	if arr.shared != nil && arr.shared.used == n && n+len(elements) <= cap(arr.Elements) {
+		arr.shared.used += len(elements)
+		return &Array{Elements: append(arr.Elements, elements...), shared: arr.shared}
+	}
+
+	// leave room to grow so the next With can append in place
+	grown := make([]Object, n, 2*(n+len(elements)))
+	copy(grown, arr.Elements)
+	grown = append(grown, elements...)
+	return &Array{Elements: grown, shared: &backing{used: len(grown)}}
+}
+
+// function that returns the elements from start up to end as a new array
+// sharing the backing array
+func (arr *Array) Slice(start, end int) *Array {
+	arr.markShared()
+	// capping the capacity makes With on the slice copy instead of
+	// appending over the elements that follow it
+	return &Array{Elements: arr.Elements[start:end:end], shared: arr.shared}
+}
+
+// function for replacing the element at index i, the elements are copied
+// first if the backing array may be shared with other arrays
+func (arr *Array) Set(i int, value Object) {
+	if arr.shared != nil {
+		elements := make([]Object, len(arr.Elements))
+		copy(elements, arr.Elements)
+		arr.Elements = elements
+		arr.shared = nil
+	}
+	arr.Elements[i] = value
+}
+
+func (arr *Array) markShared() {
+	if arr.shared == nil {
+		arr.shared = &backing{used: len(arr.Elements)}
+	}
+}
+
+// function that returns a copy of the array, nested arrays and hashes are
+// copied too so mutating the clone never affects the original
+func (arr *Array) Clone() *Array {
+	elements := make([]Object, len(arr.Elements))
+	for i, el := range arr.Elements {
+		elements[i] = clone(el)
+	}
+	return &Array{Elements: elements}
+}
+
+// function for copying the containers, other objects are immutable and
+// can be shared
+func clone(obj Object) Object {
+	switch obj := obj.(type) {
+	case *Array:
+		return obj.Clone()
+	case *Hash:
+		return obj.Clone()
+	default:
+		return obj
+	}
 }
 
 func (arr Array) Type() ObjectType {
@@ -144,6 +556,20 @@ func (arr Array) Inspect() string {
 	return out.String()
 }
 
+func (arr Array) ReplString() string {
+	var out bytes.Buffer
+	elements := []string{}
+
+	for _, e := range arr.Elements {
+		elements = append(elements, ReplString(e))
+	}
+
+	out.WriteString("[")
+	out.WriteString(strings.Join(elements, ", "))
+	out.WriteString("]")
+	return out.String()
+}
+
 // built in function
 type BuiltinFunction func(args ...Object) Object
 
@@ -160,10 +586,17 @@ func (b Builtin) Inspect() string {
 }
 
 // struct representing a string
+// Value holds UTF-8 encoded bytes, while `len` counts runes
 type String struct {
 	Value string
 }
 
+// function that returns the number of characters of the string, counted
+// in runes rather than bytes
+func (s String) Length() int {
+	return utf8.RuneCountInString(s.Value)
+}
+
 func (s String) Type() ObjectType {
 	return STRING_OBJ
 }
@@ -172,11 +605,17 @@ func (s String) Inspect() string {
 	return s.Value
 }
 
+// strings are quoted in the repl so "5" can be told apart from 5
+func (s String) ReplString() string {
+	return strconv.Quote(s.Value)
+}
+
 // struct that represensts an already compiled function
 type CompiledFunction struct {
 	Instructions  code.Instructions
-	NumLocals     int // number of local bindings used by the function
-	NumParameters int // nunmber of parameters of function
+	NumLocals     int    // number of local bindings used by the function
+	NumParameters int    // nunmber of parameters of function
+	Name          string // name of the let binding the function was defined with
 }
 
 func (cf *CompiledFunction) Type() ObjectType {
@@ -184,9 +623,30 @@ func (cf *CompiledFunction) Type() ObjectType {
 }
 
 func (cf *CompiledFunction) Inspect() string {
+	if cf.Name != "" {
+		return fmt.Sprintf("CompiledFunction[%s]", cf.Name)
+	}
 	return fmt.Sprintf("CompiledFunction[%p]", cf)
 }
 
+// struct that represents a compiled function together with the free
+// variables it captured from the functions enclosing it
+type Closure struct {
+	Fn   *CompiledFunction
+	Free []Object
+}
+
+func (c *Closure) Type() ObjectType {
+	return CLOSURE_OBJ
+}
+
+func (c *Closure) Inspect() string {
+	if c.Fn.Name != "" {
+		return fmt.Sprintf("Closure[%s]", c.Fn.Name)
+	}
+	return fmt.Sprintf("Closure[%p]", c)
+}
+
 // struct that represents a function
 type Function struct {
 	Parameters []ast.Identifier
@@ -212,15 +672,28 @@ func (f Function) Inspect() string {
 	return out.String()
 }
 
+// category of an error
+type ErrorKind string
+
+const (
+	TypeError  ErrorKind = "TypeError"
+	NameError  ErrorKind = "NameError"
+	IndexError ErrorKind = "IndexError"
+)
+
 // struct that defines an error
 type Error struct {
 	Message string
+	Kind    ErrorKind // optional category of the error
 }
 
 func (e Error) Type() ObjectType {
 	return ERROR_OBJ
 }
 func (e Error) Inspect() string {
+	if e.Kind != "" {
+		return "ERROR[" + string(e.Kind) + "]: " + e.Message
+	}
 	return "ERROR: " + e.Message
 }
 
@@ -236,12 +709,38 @@ func (rv ReturnValue) Inspect() string {
 	return rv.Value.Inspect()
 }
 
+// objects produced by break and continue, they propagate up to the
+// innermost loop like a ReturnValue propagates up to the function
+type Break struct{}
+
+func (b Break) Type() ObjectType { return BREAK_OBJ }
+func (b Break) Inspect() string  { return "break" }
+
+type Continue struct{}
+
+func (c Continue) Type() ObjectType { return CONTINUE_OBJ }
+func (c Continue) Inspect() string  { return "continue" }
+
 // struct that will wrap every object (type) in our language
 type Object interface {
 	Type() ObjectType
 	Inspect() string
 }
 
+// interface implemented by objects that are rendered differently
+// when echoed by the repl than when printed by puts
+type ReplStringer interface {
+	ReplString() string
+}
+
+// function for rendering an object the way the repl echoes it
+func ReplString(obj Object) string {
+	if r, ok := obj.(ReplStringer); ok {
+		return r.ReplString()
+	}
+	return obj.Inspect()
+}
+
 // internal representation of integer
 type Integer struct {
 	Value int64
@@ -281,11 +780,14 @@ func (i Float) Type() ObjectType {
 	return FLOAT_OBJ
 }
 
+// how null is rendered by Inspect, embedders can switch it to e.g "nil"
+var NullLiteral = "null"
+
 // internal represenation of null object
 type Null struct{}
 
 func (n Null) Inspect() string {
-	return "null"
+	return NullLiteral
 }
 
 func (i Null) Type() ObjectType {
@@ -0,0 +1,430 @@
+package object
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestCompiledFunctionInspect(t *testing.T) {
+	named := &CompiledFunction{Name: "add"}
+	require.Equal(t, "CompiledFunction[add]", named.Inspect())
+
+	anonymous := &CompiledFunction{}
+	require.Equal(t, fmt.Sprintf("CompiledFunction[%p]", anonymous), anonymous.Inspect())
+
+	closure := &Closure{Fn: named}
+	require.Equal(t, "Closure[add]", closure.Inspect())
+	anonymousClosure := &Closure{Fn: anonymous}
+	require.Equal(t, fmt.Sprintf("Closure[%p]", anonymousClosure), anonymousClosure.Inspect())
+}
+
+func TestHashCollisions(t *testing.T) {
+	// fnv collisions are impractical to find by hand, so simulate one by
+	// storing the key "a" in the slot that "b" hashes to
+	a := &String{Value: "a"}
+	b := &String{Value: "b"}
+
+	hash := &Hash{Pairs: map[HashKey]HashPair{
+		b.HashKey(): {Key: a, Value: &Integer{Value: 1}},
+	}}
+
+	_, ok := hash.Get(b)
+	require.False(t, ok)
+
+	hash.Set(b, &Integer{Value: 2})
+	require.Equal(t, 2, len(hash.Pairs))
+
+	pair, ok := hash.Get(b)
+	require.True(t, ok)
+	require.Equal(t, int64(2), pair.Value.(*Integer).Value)
+
+	hash.Set(b, &Integer{Value: 3})
+	require.Equal(t, 2, len(hash.Pairs))
+
+	pair, ok = hash.Get(b)
+	require.True(t, ok)
+	require.Equal(t, int64(3), pair.Value.(*Integer).Value)
+	require.Equal(t, int64(1), hash.Pairs[b.HashKey()].Value.(*Integer).Value)
+}
+
+func TestHashKeysAreNamespacedByType(t *testing.T) {
+	one := (&Integer{Value: 1}).HashKey()
+	yes := TRUE.HashKey()
+	zero := (&Integer{Value: 0}).HashKey()
+	no := FALSE.HashKey()
+
+	require.Equal(t, one.Value, yes.Value)
+	require.NotEqual(t, one, yes)
+	require.Equal(t, zero.Value, no.Value)
+	require.NotEqual(t, zero, no)
+
+	hash := &Hash{}
+	hash.Set(&Integer{Value: 1}, String{Value: "int"})
+	hash.Set(TRUE, String{Value: "bool"})
+	require.Len(t, hash.Pairs, 2)
+
+	pair, ok := hash.Get(&Integer{Value: 1})
+	require.True(t, ok)
+	require.Equal(t, "int", pair.Value.Inspect())
+	pair, ok = hash.Get(TRUE)
+	require.True(t, ok)
+	require.Equal(t, "bool", pair.Value.Inspect())
+}
+
+func TestStringRendering(t *testing.T) {
+	str := &String{Value: "hello"}
+	require.Equal(t, "hello", str.Inspect())
+	require.Equal(t, `"hello"`, ReplString(str))
+
+	array := &Array{Elements: []Object{str, &Integer{Value: 5}}}
+	require.Equal(t, "[hello, 5]", array.Inspect())
+	require.Equal(t, `["hello", 5]`, ReplString(array))
+
+	require.Equal(t, "5", ReplString(&Integer{Value: 5}))
+}
+
+func TestErrorInspect(t *testing.T) {
+	typed := &Error{Message: "type mismatch: integer + boolean", Kind: TypeError}
+	require.Equal(t, "ERROR[TypeError]: type mismatch: integer + boolean", typed.Inspect())
+
+	untyped := &Error{Message: "something went wrong"}
+	require.Equal(t, "ERROR: something went wrong", untyped.Inspect())
+}
+
+func TestArrayClone(t *testing.T) {
+	nested := &Array{Elements: []Object{&Integer{Value: 2}}}
+	original := &Array{Elements: []Object{&Integer{Value: 1}, nested}}
+
+	cloned := original.Clone()
+	require.Equal(t, original.Inspect(), cloned.Inspect())
+
+	cloned.Elements[0] = &Integer{Value: 10}
+	cloned.Elements = append(cloned.Elements, &Integer{Value: 3})
+	cloned.Elements[1].(*Array).Elements[0] = &Integer{Value: 20}
+
+	require.Equal(t, "[1, [2]]", original.Inspect())
+	require.Equal(t, "[10, [20], 3]", cloned.Inspect())
+}
+
+func TestHashClone(t *testing.T) {
+	nested := &Array{Elements: []Object{&Integer{Value: 1}}}
+	original := &Hash{}
+	original.Set(String{Value: "a"}, nested)
+
+	cloned := original.Clone()
+	cloned.Set(String{Value: "b"}, &Integer{Value: 2})
+	pair, ok := cloned.Get(String{Value: "a"})
+	require.True(t, ok)
+	pair.Value.(*Array).Elements[0] = &Integer{Value: 10}
+
+	require.Len(t, original.Pairs, 1)
+	_, ok = original.Get(String{Value: "b"})
+	require.False(t, ok)
+	require.Equal(t, "{a: [1]}", original.Inspect())
+}
+
+func TestNullLiteral(t *testing.T) {
+	require.Equal(t, "null", NULL.Inspect())
+
+	NullLiteral = "nil"
+	defer func() { NullLiteral = "null" }()
+
+	require.Equal(t, "nil", NULL.Inspect())
+	require.Equal(t, "[1, nil]", (&Array{Elements: []Object{&Integer{Value: 1}, NULL}}).Inspect())
+}
+
+func TestNumericPromotion(t *testing.T) {
+	testCases := []struct {
+		obj      Object
+		expected float64
+		numeric  bool
+	}{
+		{&Integer{Value: 3}, 3, true},
+		{&Float{Value: 2.5}, 2.5, true},
+		{String{Value: "1"}, 0, false},
+		{TRUE, 0, false},
+		{NULL, 0, false},
+	}
+
+	for _, tc := range testCases {
+		value, ok := ToFloat(tc.obj)
+		require.Equal(t, tc.numeric, ok)
+		require.Equal(t, tc.expected, value)
+		require.Equal(t, tc.numeric, Numeric(tc.obj))
+	}
+}
+
+func TestIsTruthy(t *testing.T) {
+	testCases := []struct {
+		obj      Object
+		expected bool
+	}{
+		{TRUE, true},
+		{FALSE, false},
+		{NULL, false},
+		// booleans and nulls that aren't the global instances
+		{&Boolean{Value: false}, false},
+		{&Boolean{Value: true}, true},
+		{&Null{}, false},
+		{&Integer{Value: 0}, true},
+		{&Float{Value: 0}, true},
+		{String{Value: ""}, true},
+		{&String{Value: ""}, true},
+		{&Array{}, true},
+		{&Hash{Pairs: map[HashKey]HashPair{}}, true},
+		{&CompiledFunction{}, true},
+		{&Error{Message: "boom"}, true},
+	}
+
+	for _, tc := range testCases {
+		require.Equal(t, tc.expected, IsTruthy(tc.obj), tc.obj.Inspect())
+	}
+}
+
+func TestObjectTypesAreValid(t *testing.T) {
+	objects := []Object{
+		&Integer{Value: 1},
+		&Boolean{Value: true},
+		&Float{Value: 1.5},
+		&Null{},
+		&ReturnValue{Value: &Integer{Value: 1}},
+		&Error{Message: "boom"},
+		Function{},
+		String{Value: "a"},
+		&String{Value: "a"},
+		&Builtin{},
+		&Array{},
+		&Hash{Pairs: map[HashKey]HashPair{}},
+		&CompiledFunction{},
+		&Closure{Fn: &CompiledFunction{}},
+		&Break{},
+		&Continue{},
+	}
+
+	seen := map[ObjectType]bool{}
+	for _, obj := range objects {
+		require.True(t, obj.Type().IsValid(), string(obj.Type()))
+		seen[obj.Type()] = true
+	}
+	// every known type is returned by some object
+	require.Equal(t, len(AllObjectTypes()), len(seen))
+
+	require.False(t, ObjectType("INTEGR").IsValid())
+	require.False(t, ObjectType("").IsValid())
+}
+
+func TestObjectTypeDisplay(t *testing.T) {
+	testCases := []struct {
+		typ      ObjectType
+		expected string
+	}{
+		{INTEGER_OBJ, "integer"},
+		{BOOLEAN_OBJ, "boolean"},
+		{STRING_OBJ, "string"},
+		{Builtin_OBJ, "builtin"},
+		{FUNCTION_OBJ, "function"},
+		{COMPILED_FUNCTION_OBJECT, "function"},
+		{CLOSURE_OBJ, "function"},
+		{RETURN_VALUE_OBJ, "return value"},
+	}
+
+	for _, tc := range testCases {
+		require.Equal(t, tc.expected, tc.typ.Display())
+	}
+
+	_, err := Arithmetic("+", &Integer{Value: 1}, &Boolean{Value: true})
+	require.EqualError(t, err, "unsupported arithmetic: integer + boolean")
+}
+
+func TestArrayWith(t *testing.T) {
+	one, two, three := &Integer{Value: 1}, &Integer{Value: 2}, &Integer{Value: 3}
+
+	base := &Array{Elements: []Object{one}}
+	a := base.With(two)
+	b := a.With(three)
+	// a second array built on a doesn't overwrite the element b appended
+	c := a.With(one)
+
+	require.Equal(t, "[1]", base.Inspect())
+	require.Equal(t, "[1, 2]", a.Inspect())
+	require.Equal(t, "[1, 2, 3]", b.Inspect())
+	require.Equal(t, "[1, 2, 1]", c.Inspect())
+
+	// chained appends grow in place instead of copying every time
+	require.Same(t, &a.Elements[0], &b.Elements[0])
+
+	// writes copy before touching a shared backing array
+	b.Set(0, three)
+	a.Set(1, three)
+	require.Equal(t, "[1, 3]", a.Inspect())
+	require.Equal(t, "[3, 2, 3]", b.Inspect())
+	require.Equal(t, "[1, 2, 1]", c.Inspect())
+}
+
+func TestArraySlice(t *testing.T) {
+	arr := &Array{Elements: []Object{&Integer{Value: 1}, &Integer{Value: 2}, &Integer{Value: 3}}}
+
+	rest := arr.Slice(1, 3)
+	first := arr.Slice(0, 1)
+	require.Equal(t, "[2, 3]", rest.Inspect())
+	require.Equal(t, "[1]", first.Inspect())
+
+	// appending to a slice doesn't overwrite the elements following it
+	grown := first.With(&Integer{Value: 10})
+	require.Equal(t, "[1, 10]", grown.Inspect())
+	require.Equal(t, "[1, 2, 3]", arr.Inspect())
+
+	rest.Set(0, &Integer{Value: 20})
+	arr.Set(2, &Integer{Value: 30})
+	require.Equal(t, "[20, 3]", rest.Inspect())
+	require.Equal(t, "[1, 2, 30]", arr.Inspect())
+}
+
+func TestArraySetOwnedElements(t *testing.T) {
+	// an array that shares nothing is written in place
+	arr := &Array{Elements: []Object{&Integer{Value: 1}}}
+	elements := arr.Elements
+	arr.Set(0, &Integer{Value: 2})
+	require.Same(t, &elements[0], &arr.Elements[0])
+	require.Equal(t, "[2]", arr.Inspect())
+}
+
+// chained pushes the way builtins used to do them, cloning the whole array
+// every time, against With
+func BenchmarkArrayPush(b *testing.B) {
+	const size = 1000
+
+	b.Run("clone", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			arr := &Array{}
+			for j := 0; j < size; j++ {
+				arr = arr.Clone()
+				arr.Elements = append(arr.Elements, &Integer{Value: int64(j)})
+			}
+		}
+	})
+
+	b.Run("with", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			arr := &Array{}
+			for j := 0; j < size; j++ {
+				arr = arr.With(&Integer{Value: int64(j)})
+			}
+		}
+	})
+}
+
+func TestClockIsMonotonic(t *testing.T) {
+	clock := GetBuiltinByName("clock")
+	first := clock.Fn().(*Integer).Value
+	second := clock.Fn().(*Integer).Value
+
+	require.GreaterOrEqual(t, first, int64(0))
+	require.GreaterOrEqual(t, second, first)
+}
+
+func TestArithmetic(t *testing.T) {
+	integer := func(v int64) Object { return &Integer{Value: v} }
+	float := func(v float64) Object { return &Float{Value: v} }
+
+	testCases := []struct {
+		operator      string
+		left, right   Object
+		expected      Object
+		expectedError string
+	}{
+		{"+", integer(1), integer(2), integer(3), ""},
+		{"/", integer(7), integer(2), integer(3), ""},
+		{"/", integer(-7), integer(2), integer(-3), ""},
+		{"/", integer(7), float(2), float(3.5), ""},
+		{"/", float(7), integer(2), float(3.5), ""},
+		{"*", float(1.5), float(2), float(3), ""},
+		{"-", integer(1), float(0.5), float(0.5), ""},
+		{"/", integer(1), integer(0), nil, "division by zero: 1 / 0"},
+		{"+", integer(1), String{Value: "a"}, nil, "unsupported arithmetic: integer + string"},
+		{"<", integer(1), integer(2), nil, "unsupported arithmetic: integer < integer"},
+	}
+
+	for _, tc := range testCases {
+		result, err := Arithmetic(tc.operator, tc.left, tc.right)
+		if tc.expectedError != "" {
+			require.EqualError(t, err, tc.expectedError)
+			continue
+		}
+		require.NoError(t, err)
+		require.Equal(t, tc.expected, result)
+	}
+}
+
+func TestSyncEnvironment(t *testing.T) {
+	env := NewSyncEnvironment()
+	env.Set("shared", &Integer{Value: 0})
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			enclosed := NewEnclosedEnvironment(env)
+			for j := 0; j < 100; j++ {
+				name := fmt.Sprintf("g%d", i)
+				env.Set(name, &Integer{Value: int64(j)})
+				_, ok := enclosed.Get(name)
+				require.True(t, ok)
+				_, ok = env.Get("shared")
+				require.True(t, ok)
+				env.Snapshot()
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	for i := 0; i < 8; i++ {
+		obj, ok := env.Get(fmt.Sprintf("g%d", i))
+		require.True(t, ok)
+		require.Equal(t, int64(99), obj.(*Integer).Value)
+	}
+}
+
+func TestLength(t *testing.T) {
+	hash := &Hash{}
+	hash.Set(String{Value: "a"}, &Integer{Value: 1})
+	hash.Set(&Integer{Value: 2}, &Integer{Value: 2})
+
+	testCases := []struct {
+		obj      Sized
+		expected int
+	}{
+		{&Array{}, 0},
+		{&Array{Elements: []Object{&Integer{Value: 1}, &Integer{Value: 2}}}, 2},
+		{&Hash{}, 0},
+		{hash, 2},
+		{String{Value: ""}, 0},
+		{String{Value: "monkey"}, 6},
+		// strings are measured in runes, not bytes
+		{String{Value: "héllo"}, 5},
+		{&String{Value: "日本語"}, 3},
+	}
+
+	for _, tc := range testCases {
+		require.Equal(t, tc.expected, tc.obj.Length())
+	}
+}
+
+func TestLenBuiltin(t *testing.T) {
+	length := GetBuiltinByName("len")
+
+	hash := &Hash{}
+	hash.Set(String{Value: "a"}, &Integer{Value: 1})
+	require.Equal(t, int64(1), length.Fn(hash).(*Integer).Value)
+	require.Equal(t, int64(3), length.Fn(&String{Value: "abc"}).(*Integer).Value)
+
+	for _, arg := range []Object{&Integer{Value: 1}, &Float{Value: 1.5}, TRUE, NULL, &Builtin{}} {
+		err, ok := length.Fn(arg).(*Error)
+		require.True(t, ok)
+		require.Equal(t, "argument to `len` not supported, got "+arg.Type().Display(), err.Message)
+	}
+}
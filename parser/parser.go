@@ -3,6 +3,7 @@ package parser
 import (
 	"fmt"
 	"strconv"
+	"strings"
 
 	"github.com/stevensopilidis/monkey/ast"
 	"github.com/stevensopilidis/monkey/lexer"
@@ -12,6 +13,10 @@ import (
 const (
 	_           int = iota
 	LOWEST          // lowest precedence
+	ASSIGN          // a[i] = x
+	NULLISH         // ??
+	LOGICAL_OR      // ||
+	LOGICAL_AND     // &&
 	EQUALS          // ==
 	LESSGREATER     // < || >
 	SUM             // +
@@ -23,11 +28,17 @@ const (
 
 // precedences of operators map
 var precedences = map[token.TokenType]int{
+	token.ASSIGN:   ASSIGN,
 	token.LPAREN:   CALL,
+	token.NULLISH:  NULLISH,
+	token.OR:       LOGICAL_OR,
+	token.AND:      LOGICAL_AND,
 	token.EQ:       EQUALS,
 	token.NOT_EQ:   EQUALS,
 	token.LT:       LESSGREATER,
 	token.GT:       LESSGREATER,
+	token.LT_EQ:    LESSGREATER,
+	token.GT_EQ:    LESSGREATER,
 	token.PLUS:     SUM,
 	token.MINUS:    SUM,
 	token.SLASH:    PRODUCT,
@@ -50,6 +61,10 @@ type Parser struct {
 
 	prefixParseFns map[token.TokenType]prefixParseFn
 	infixParseFns  map[token.TokenType]infixParseFn
+
+	// when enabled bare identifier keys of hash literals are parsed as
+	// strings like in JSON shorthand, so {name: 1} is {"name": 1}
+	StringKeys bool
 }
 
 func New(l *lexer.Lexer) *Parser {
@@ -65,11 +80,14 @@ func New(l *lexer.Lexer) *Parser {
 	p.registerPrefix(token.FLOAT, p.parseFloatLiteral)
 	p.registerPrefix(token.BANG, p.parsePrefixExpression)
 	p.registerPrefix(token.MINUS, p.parsePrefixExpression)
+	p.registerPrefix(token.PLUS, p.parsePrefixExpression)
 	p.registerPrefix(token.TRUE, p.parseBooleanExpression)
 	p.registerPrefix(token.FALSE, p.parseBooleanExpression)
 	p.registerPrefix(token.LPAREN, p.parseGroupedExpression)
 	p.registerPrefix(token.IF, p.parseIfExpression)
+	p.registerPrefix(token.DO, p.parseDoExpression)
 	p.registerPrefix(token.FUNCTION, p.parseFunctionLiteral)
+	p.registerPrefix(token.BACKSLASH, p.parseLambda)
 	p.registerPrefix(token.STRING, p.parseStringLiteral)
 	p.registerPrefix(token.LBRACKET, p.parseArrayLiteral)
 	p.registerPrefix(token.LBRACE, p.parseHashLiteral)
@@ -83,8 +101,14 @@ func New(l *lexer.Lexer) *Parser {
 	p.registerInfix(token.NOT_EQ, p.parseInfixExpression)
 	p.registerInfix(token.LT, p.parseInfixExpression)
 	p.registerInfix(token.GT, p.parseInfixExpression)
+	p.registerInfix(token.AND, p.parseInfixExpression)
+	p.registerInfix(token.OR, p.parseInfixExpression)
+	p.registerInfix(token.NULLISH, p.parseInfixExpression)
+	p.registerInfix(token.LT_EQ, p.parseInfixExpression)
+	p.registerInfix(token.GT_EQ, p.parseInfixExpression)
 	p.registerInfix(token.LPAREN, p.parseCallExpression)
 	p.registerInfix(token.LBRACKET, p.parseIndexExpression)
+	p.registerInfix(token.ASSIGN, p.parseAssignExpression)
 
 	// set current and peek token
 	p.nextToken()
@@ -96,12 +120,18 @@ func New(l *lexer.Lexer) *Parser {
 // function for parsing hash literals
 func (p *Parser) parseHashLiteral() ast.Expression {
 	hash := ast.HashLiteral{Token: p.curToken}
-	hash.Pairs = make(map[ast.Expression]ast.Expression)
+	hash.Pairs = []ast.HashLiteralPair{}
 
 	for !p.peekTokenIs(token.RBRACE) {
 		p.nextToken()
 		// parse the key
-		key := p.parseExpression(LOWEST)
+		var key ast.Expression
+		if p.StringKeys && p.curTokenIs(token.IDENT) && p.peekTokenIs(token.COLON) {
+			tok := token.Token{Type: token.STRING, Literal: p.curToken.Literal, Line: p.curToken.Line}
+			key = ast.StringLiteral{Token: tok, Value: tok.Literal}
+		} else {
+			key = p.parseExpression(LOWEST)
+		}
 
 		if !p.expectPeek(token.COLON) {
 			return nil
@@ -111,7 +141,7 @@ func (p *Parser) parseHashLiteral() ast.Expression {
 		// parse the value
 		value := p.parseExpression(LOWEST)
 
-		hash.Pairs[key] = value
+		hash.Pairs = append(hash.Pairs, ast.HashLiteralPair{Key: key, Value: value})
 
 		// if we have not reached the end and there is no command seperating the
 		// key-value pairs
@@ -141,10 +171,33 @@ func (p *Parser) parseIndexExpression(left ast.Expression) ast.Expression {
 	return exp
 }
 
+// function for parsing assignments, the value is parsed with the lowest
+// precedence so assignments are right associative (a[0] = b[0] = 1)
+func (p *Parser) parseAssignExpression(target ast.Expression) ast.Expression {
+	exp := ast.AssignExpression{Token: p.curToken, Target: target}
+
+	if _, ok := target.(ast.IndexExpression); !ok {
+		msg := fmt.Sprintf("invalid assignment target: %s", target.String())
+		p.errors = append(p.errors, msg)
+		return nil
+	}
+
+	p.nextToken()
+	exp.Value = p.parseExpression(LOWEST)
+	if exp.Value == nil {
+		return nil
+	}
+
+	return exp
+}
+
 // function for parsing arrays
 func (p *Parser) parseArrayLiteral() ast.Expression {
 	array := ast.ArrayLiteral{Token: p.curToken}
 	array.Elements = p.parseExpressionList(token.RBRACKET)
+	if array.Elements == nil {
+		return nil
+	}
 
 	return array
 }
@@ -183,10 +236,15 @@ func (p *Parser) parseStringLiteral() ast.Expression {
 func (p *Parser) parseCallExpression(function ast.Expression) ast.Expression {
 	exp := ast.CallExpression{Token: p.curToken, Function: function}
 	exp.Arguments = p.parseExpressionList(token.RPAREN)
+	if exp.Arguments == nil {
+		return nil
+	}
 	return exp
 }
 
 // function for parsing the parameters of the function literal
+// the returned slice is never nil on success so a function without
+// parameters has an empty list, nil signals a parse error
 func (p *Parser) parseFunctionParameters() []ast.Identifier {
 	identifiers := []ast.Identifier{}
 
@@ -196,14 +254,17 @@ func (p *Parser) parseFunctionParameters() []ast.Identifier {
 		return identifiers
 	}
 
-	p.nextToken()
-
+	if !p.expectPeek(token.IDENT) {
+		return nil
+	}
 	ident := ast.Identifier{Token: p.curToken, Value: p.curToken.Literal}
 	identifiers = append(identifiers, ident)
 
 	for p.peekTokenIs(token.COMMA) {
 		p.nextToken()
-		p.nextToken()
+		if !p.expectPeek(token.IDENT) {
+			return nil
+		}
 		ident := ast.Identifier{Token: p.curToken, Value: p.curToken.Literal}
 		identifiers = append(identifiers, ident)
 	}
@@ -224,6 +285,19 @@ func (p *Parser) parseFunctionLiteral() ast.Expression {
 	}
 
 	lit.Parameters = p.parseFunctionParameters()
+	if lit.Parameters == nil {
+		return nil
+	}
+
+	// fn(x) => x + 1 is the same as fn(x) { x + 1 }
+	if p.peekTokenIs(token.FAT_ARROW) {
+		p.nextToken()
+		lit.Body = p.parseExpressionBody()
+		if lit.Body == nil {
+			return nil
+		}
+		return lit
+	}
 
 	if !p.expectPeek(token.LBRACE) {
 		return nil
@@ -234,6 +308,48 @@ func (p *Parser) parseFunctionLiteral() ast.Expression {
 	return lit
 }
 
+// function for parsing the lambda shorthand \(x, y) -> x + y, it desugars
+// into the function literal fn(x, y) { x + y }
+func (p *Parser) parseLambda() ast.Expression {
+	fnToken := token.Token{Type: token.FUNCTION, Literal: "fn", Line: p.curToken.Line}
+	lit := ast.FunctionLiteral{Token: fnToken}
+
+	if !p.expectPeek(token.LPAREN) {
+		return nil
+	}
+
+	lit.Parameters = p.parseFunctionParameters()
+	if lit.Parameters == nil {
+		return nil
+	}
+
+	if !p.expectPeek(token.ARROW) {
+		return nil
+	}
+	lit.Body = p.parseExpressionBody()
+	if lit.Body == nil {
+		return nil
+	}
+
+	return lit
+}
+
+// function for parsing the single expression following the arrow of a
+// lambda or an expression bodied function, it's wrapped in a block whose
+// value is the value of the expression
+func (p *Parser) parseExpressionBody() *ast.BlockStatement {
+	arrow := p.curToken
+	p.nextToken()
+
+	stmt := ast.ExpressionStatement{Token: p.curToken}
+	stmt.Expression = p.parseExpression(LOWEST)
+	if stmt.Expression == nil {
+		return nil
+	}
+
+	return &ast.BlockStatement{Token: arrow, Statements: []ast.Statement{stmt}}
+}
+
 // function for parsing if expression
 func (p *Parser) parseIfExpression() ast.Expression {
 	expression := ast.IfExpression{Token: p.curToken}
@@ -261,6 +377,22 @@ func (p *Parser) parseIfExpression() ast.Expression {
 	if p.peekTokenIs(token.ELSE) {
 		p.nextToken()
 
+		// else if, the nested if expression becomes the only
+		// statement of the alternative block
+		if p.peekTokenIs(token.IF) {
+			p.nextToken()
+			tok := p.curToken
+			nested := p.parseIfExpression()
+			if nested == nil {
+				return nil
+			}
+			expression.Alternative = &ast.BlockStatement{
+				Token:      tok,
+				Statements: []ast.Statement{ast.ExpressionStatement{Token: tok, Expression: nested}},
+			}
+			return expression
+		}
+
 		if !p.expectPeek(token.LBRACE) {
 			return nil
 		}
@@ -271,6 +403,47 @@ func (p *Parser) parseIfExpression() ast.Expression {
 	return expression
 }
 
+// function for parsing while loops
+func (p *Parser) parseWhileStatement() ast.Statement {
+	stmt := ast.WhileStatement{Token: p.curToken}
+
+	if !p.expectPeek(token.LPAREN) {
+		return nil
+	}
+
+	p.nextToken()
+	stmt.Condition = p.parseExpression(LOWEST)
+
+	if !p.expectPeek(token.RPAREN) {
+		return nil
+	}
+
+	if !p.expectPeek(token.LBRACE) {
+		return nil
+	}
+
+	stmt.Body = p.parseBlockStatement()
+
+	if p.peekTokenIs(token.SEMICOLON) {
+		p.nextToken()
+	}
+
+	return stmt
+}
+
+// function for parsing do expressions
+func (p *Parser) parseDoExpression() ast.Expression {
+	expression := ast.DoExpression{Token: p.curToken}
+
+	if !p.expectPeek(token.LBRACE) {
+		return nil
+	}
+
+	expression.Body = p.parseBlockStatement()
+
+	return expression
+}
+
 // function for parsing a block statement
 func (p *Parser) parseBlockStatement() *ast.BlockStatement {
 	block := &ast.BlockStatement{Token: p.curToken}
@@ -282,10 +455,17 @@ func (p *Parser) parseBlockStatement() *ast.BlockStatement {
 		stmt := p.parseStatement()
 		if stmt != nil {
 			block.Statements = append(block.Statements, stmt)
+			p.checkStatementSeparator()
 		}
 		p.nextToken()
 	}
 
+	if p.curTokenIs(token.EOF) {
+		msg := fmt.Sprintf("unclosed block: expected } to close the block opened on line %d",
+			block.Token.Line)
+		p.errors = append(p.errors, msg)
+	}
+
 	return block
 }
 
@@ -309,7 +489,7 @@ func (p *Parser) parseBooleanExpression() ast.Expression {
 func (p *Parser) parseInfixExpression(left ast.Expression) ast.Expression {
 	expression := ast.InfixExpression{
 		Token:    p.curToken,
-		Operator: p.curToken.Literal,
+		Operator: operator(p.curToken),
 		Left:     left,
 	}
 
@@ -326,11 +506,18 @@ func (p *Parser) noPrefixParseFnError(t token.TokenType) {
 	p.errors = append(p.errors, msg)
 }
 
+// function that returns the operator of an operator token, keyword aliases
+// (not, and, or) share the token type of their symbolic form which is the
+// operator itself
+func operator(tok token.Token) string {
+	return string(tok.Type)
+}
+
 // function for parsing PrefixExpressions (<prefix_operator><expression>)
 func (p *Parser) parsePrefixExpression() ast.Expression {
 	expression := ast.PrefixExpression{
 		Token:    p.curToken,
-		Operator: p.curToken.Literal,
+		Operator: operator(p.curToken),
 	}
 
 	p.nextToken()
@@ -362,10 +549,16 @@ func (p *Parser) parseIntegerLiteral() ast.Expression {
 
 func (p *Parser) parseFloatLiteral() ast.Expression {
 	lit := ast.FloatLiteral{Token: p.curToken}
-	val, err := strconv.ParseFloat(p.curToken.Literal, 64)
+
+	// the lexer produces `.5` for floats with a leading dot
+	literal := p.curToken.Literal
+	if strings.HasPrefix(literal, ".") {
+		literal = "0" + literal
+	}
+	val, err := strconv.ParseFloat(literal, 64)
 
 	if err != nil {
-		msg := fmt.Sprintf("could not parse %q as integer", p.curToken.Literal)
+		msg := fmt.Sprintf("could not parse %q as float", p.curToken.Literal)
 		p.errors = append(p.errors, msg)
 		return nil
 	}
@@ -393,9 +586,13 @@ func (p *Parser) ParseProgram() *ast.Program {
 	program.Statements = []ast.Statement{}
 
 	for p.curToken.Type != token.EOF {
+		errorsBefore := len(p.errors)
 		stmt := p.parseStatement()
-		if stmt != nil {
+		if stmt == nil && len(p.errors) > errorsBefore {
+			p.synchronize()
+		} else if stmt != nil {
 			program.Statements = append(program.Statements, stmt)
+			p.checkStatementSeparator()
 		}
 
 		p.nextToken()
@@ -404,23 +601,78 @@ func (p *Parser) ParseProgram() *ast.Program {
 	return program
 }
 
+// function for recovering from a statement that failed to parse, the rest
+// of it is skipped up to the next semicolon or a token that starts a new
+// statement so one error doesn't cascade into the statements that follow
+func (p *Parser) synchronize() {
+	for !p.curTokenIs(token.SEMICOLON) && !p.curTokenIs(token.EOF) {
+		switch p.peekToken.Type {
+		case token.LET, token.RETURN, token.IF, token.FUNCTION, token.WHILE, token.EOF:
+			return
+		}
+		p.nextToken()
+	}
+}
+
+// function that checks that the statement that was just parsed is separated
+// from the next one, semicolons are optional between statements on different
+// lines but statements sharing a line need one unless the next statement
+// starts with a keyword (e.g. `1 2` is ambiguous but `let a = 1 let b = 2` is not)
+func (p *Parser) checkStatementSeparator() {
+	if p.curTokenIs(token.SEMICOLON) || p.peekToken.Line != p.curToken.Line {
+		return
+	}
+
+	switch p.peekToken.Type {
+	case token.RBRACE, token.EOF, token.SEMICOLON, token.LET, token.RETURN,
+		token.WHILE, token.BREAK, token.CONTINUE:
+		return
+	}
+
+	msg := fmt.Sprintf("expected ; or newline before %q on line %d",
+		p.peekToken.Literal, p.peekToken.Line)
+	p.errors = append(p.errors, msg)
+}
+
 // function for parsing statements
 func (p *Parser) parseStatement() ast.Statement {
 	switch p.curToken.Type {
+	case token.SEMICOLON: // a stray semicolon is an empty statement
+		return nil
 	case token.LET: // parse a let statement
 		return p.parseLetStatement()
 	case token.RETURN: // parse a return statement
 		return p.parseReturnStatement()
+	case token.WHILE: // parse a while loop
+		return p.parseWhileStatement()
+	case token.BREAK:
+		stmt := ast.BreakStatement{Token: p.curToken}
+		if p.peekTokenIs(token.SEMICOLON) {
+			p.nextToken()
+		}
+		return stmt
+	case token.CONTINUE:
+		stmt := ast.ContinueStatement{Token: p.curToken}
+		if p.peekTokenIs(token.SEMICOLON) {
+			p.nextToken()
+		}
+		return stmt
 	default:
 		return p.parseExpressionStatement()
 	}
 }
 
-func (p *Parser) parseExpressionStatement() ast.ExpressionStatement {
+func (p *Parser) parseExpressionStatement() ast.Statement {
 	stmt := ast.ExpressionStatement{Token: p.curToken}
 
+	errorsBefore := len(p.errors)
 	stmt.Expression = p.parseExpression(LOWEST)
 
+	// drop the statement if the expression could not be parsed
+	if stmt.Expression == nil && len(p.errors) > errorsBefore {
+		return nil
+	}
+
 	if p.peekTokenIs(token.SEMICOLON) {
 		p.nextToken()
 	}
@@ -430,6 +682,12 @@ func (p *Parser) parseExpressionStatement() ast.ExpressionStatement {
 
 func (p *Parser) parseExpression(precedence int) ast.Expression {
 	prefix := p.prefixParseFns[p.curToken.Type]
+	if prefix == nil && p.curTokenIs(token.ILLEGAL) {
+		// echo the offending text the lexer kept in the literal
+		msg := fmt.Sprintf("illegal token %q on line %d", p.curToken.Literal, p.curToken.Line)
+		p.errors = append(p.errors, msg)
+		return nil
+	}
 	if prefix == nil {
 		p.noPrefixParseFnError(p.curToken.Type)
 		return nil
@@ -437,7 +695,8 @@ func (p *Parser) parseExpression(precedence int) ast.Expression {
 
 	leftExp := prefix()
 
-	for !p.peekTokenIs(token.SEMICOLON) && precedence < p.peekPredecence() {
+	// a failed operand ends the expression, the error was already reported
+	for leftExp != nil && !p.peekTokenIs(token.SEMICOLON) && precedence < p.peekPredecence() {
 		infix := p.infixParseFns[p.peekToken.Type]
 		if infix == nil {
 			return leftExp
@@ -454,11 +713,30 @@ func (p *Parser) parseExpression(precedence int) ast.Expression {
 func (p *Parser) parseLetStatement() ast.Statement {
 	stmt := ast.LetStatement{Token: p.curToken}
 
-	if !p.expectPeek(token.IDENT) {
-		return nil
-	}
+	if p.peekTokenIs(token.LBRACKET) {
+		p.nextToken()
+		stmt.Pattern = p.parseArrayPattern()
+		if stmt.Pattern == nil {
+			return nil
+		}
+	} else {
+		if !p.expectPeek(token.IDENT) {
+			return nil
+		}
 
-	stmt.Name = ast.Identifier{Token: p.curToken, Value: p.curToken.Literal}
+		stmt.Name = ast.Identifier{Token: p.curToken, Value: p.curToken.Literal}
+
+		// let a, b = f() is shorthand for let [a, b] = f()
+		if p.peekTokenIs(token.COMMA) {
+			pattern := &ast.ArrayPattern{Token: p.curToken, Elements: []ast.Identifier{stmt.Name}}
+			p.nextToken()
+			if !p.parsePatternElements(pattern) {
+				return nil
+			}
+			stmt.Name = ast.Identifier{}
+			stmt.Pattern = pattern
+		}
+	}
 
 	if !p.expectPeek(token.ASSIGN) {
 		return nil
@@ -467,20 +745,87 @@ func (p *Parser) parseLetStatement() ast.Statement {
 	p.nextToken()
 
 	stmt.Value = p.parseExpression(LOWEST)
+	if stmt.Value == nil {
+		return nil
+	}
 
-	for !p.curTokenIs(token.SEMICOLON) {
+	if p.peekTokenIs(token.SEMICOLON) {
 		p.nextToken()
 	}
 
 	return stmt
 }
 
+// function for parsing the target of a destructuring let, a rest
+// element (...name) can only come last
+func (p *Parser) parseArrayPattern() *ast.ArrayPattern {
+	pattern := &ast.ArrayPattern{Token: p.curToken}
+
+	if p.peekTokenIs(token.RBRACKET) {
+		p.nextToken()
+		return pattern
+	}
+
+	if !p.parsePatternElements(pattern) || !p.expectPeek(token.RBRACKET) {
+		return nil
+	}
+
+	return pattern
+}
+
+// function for parsing the comma separated elements of a pattern into
+// pattern, returns false if they are malformed
+func (p *Parser) parsePatternElements(pattern *ast.ArrayPattern) bool {
+	for {
+		if p.peekTokenIs(token.ELLIPSIS) {
+			p.nextToken()
+			if !p.expectPeek(token.IDENT) {
+				return false
+			}
+			pattern.Rest = &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal}
+			return true
+		}
+
+		if !p.expectPeek(token.IDENT) {
+			return false
+		}
+		pattern.Elements = append(pattern.Elements,
+			ast.Identifier{Token: p.curToken, Value: p.curToken.Literal})
+
+		if !p.peekTokenIs(token.COMMA) {
+			return true
+		}
+		p.nextToken()
+	}
+}
+
 func (p *Parser) parseReturnStatement() ast.Statement {
 	stmt := ast.ReturnStatement{Token: p.curToken}
 
 	p.nextToken()
 
 	stmt.ReturnValue = p.parseExpression(LOWEST)
+	if stmt.ReturnValue == nil {
+		return nil
+	}
+
+	// return a, b returns the values as an array
+	if p.peekTokenIs(token.COMMA) {
+		values := ast.ArrayLiteral{
+			Token:    token.Token{Type: token.LBRACKET, Literal: "[", Line: p.curToken.Line},
+			Elements: []ast.Expression{stmt.ReturnValue},
+		}
+		for p.peekTokenIs(token.COMMA) {
+			p.nextToken()
+			p.nextToken()
+			value := p.parseExpression(LOWEST)
+			if value == nil {
+				return nil
+			}
+			values.Elements = append(values.Elements, value)
+		}
+		stmt.ReturnValue = values
+	}
 
 	if p.peekTokenIs(token.SEMICOLON) {
 		p.nextToken()
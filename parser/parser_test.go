@@ -29,11 +29,11 @@ func TestParsingHashLiteralStringKeys(t *testing.T) {
 		"three": 3,
 	}
 
-	for key, value := range hash.Pairs {
-		literal, ok := key.(ast.StringLiteral)
+	for _, pair := range hash.Pairs {
+		literal, ok := pair.Key.(ast.StringLiteral)
 		require.True(t, ok)
 		expectedValue := expected[literal.String()]
-		testIntOrFloatLiteral(t, value, fmt.Sprint(expectedValue))
+		testIntOrFloatLiteral(t, pair.Value, fmt.Sprint(expectedValue))
 	}
 }
 
@@ -75,14 +75,14 @@ func TestParsingHashLiteralsWithExpressions(t *testing.T) {
 		},
 	}
 
-	for key, value := range hash.Pairs {
-		literal, ok := key.(ast.StringLiteral)
+	for _, pair := range hash.Pairs {
+		literal, ok := pair.Key.(ast.StringLiteral)
 		require.True(t, ok)
 
 		testFunc, ok := tests[literal.String()]
 		require.True(t, ok)
 
-		testFunc(value)
+		testFunc(pair.Value)
 	}
 }
 
@@ -172,6 +172,69 @@ func testLetStatement(t *testing.T, s ast.Statement, name string) {
 	require.Equal(t, name, letStmt.Name.TokenLiteral())
 }
 
+func TestDestructuringLetStatements(t *testing.T) {
+	testCases := []struct {
+		input            string
+		expectedElements []string
+		expectedRest     string
+		expectedString   string
+	}{
+		{"let [a, b] = [1, 2];", []string{"a", "b"}, "", "let [a, b] = [1, 2];"},
+		{"let [head, ...tail] = xs;", []string{"head"}, "tail", "let [head, ...tail] = xs;"},
+		{"let [...all] = xs;", nil, "all", "let [...all] = xs;"},
+		{"let [] = xs;", nil, "", "let [] = xs;"},
+		{"let q, r = divmod(17, 5);", []string{"q", "r"}, "", "let [q, r] = divmod(17, 5);"},
+		{"let head, ...tail = xs;", []string{"head"}, "tail", "let [head, ...tail] = xs;"},
+	}
+
+	for _, tc := range testCases {
+		l := lexer.New(tc.input)
+		p := New(l)
+		program := p.ParseProgram()
+		checkParserErrors(t, p)
+
+		require.Equal(t, 1, len(program.Statements))
+		stmt, ok := program.Statements[0].(ast.LetStatement)
+		require.True(t, ok)
+		require.NotNil(t, stmt.Pattern)
+
+		var elements []string
+		for _, el := range stmt.Pattern.Elements {
+			elements = append(elements, el.Value)
+		}
+		require.Equal(t, tc.expectedElements, elements)
+
+		if tc.expectedRest == "" {
+			require.Nil(t, stmt.Pattern.Rest)
+		} else {
+			require.Equal(t, tc.expectedRest, stmt.Pattern.Rest.Value)
+		}
+		require.Equal(t, tc.expectedString, program.String())
+	}
+}
+
+func TestInvalidArrayPatterns(t *testing.T) {
+	testCases := []struct {
+		input         string
+		expectedError string
+	}{
+		{"let [a, 1] = xs;", "expected next token to be IDENT, got INT instead"},
+		{"let [...rest, a] = xs;", "expected next token to be ], got , instead"},
+		{"let [a b] = xs;", "expected next token to be ], got IDENT instead"},
+		{"let [a] xs;", "expected next token to be =, got IDENT instead"},
+		{"let a, 1 = xs;", "expected next token to be IDENT, got INT instead"},
+		{"let a, b xs;", "expected next token to be =, got IDENT instead"},
+	}
+
+	for _, tc := range testCases {
+		l := lexer.New(tc.input)
+		p := New(l)
+		p.ParseProgram()
+
+		require.Contains(t, p.Errors(), tc.expectedError)
+	}
+}
+
 func TestReturnStatements(t *testing.T) {
 	testsCases := []struct {
 		input         string
@@ -196,6 +259,46 @@ func TestReturnStatements(t *testing.T) {
 	}
 }
 
+func TestReturnMultipleValues(t *testing.T) {
+	testCases := []struct {
+		input    string
+		expected string
+	}{
+		{"return a, b;", "return [a, b];"},
+		{"return 1, 2 + 3, f(x, y)", "return [1, (2 + 3), f(x, y)];"},
+		{"return [1], 2;", "return [[1], 2];"},
+	}
+
+	for _, tc := range testCases {
+		l := lexer.New(tc.input)
+		p := New(l)
+		program := p.ParseProgram()
+		checkParserErrors(t, p)
+		require.Equal(t, 1, len(program.Statements))
+
+		returnStmt, ok := program.Statements[0].(ast.ReturnStatement)
+		require.True(t, ok)
+		_, ok = returnStmt.ReturnValue.(ast.ArrayLiteral)
+		require.True(t, ok)
+		require.Equal(t, tc.expected, program.String())
+	}
+}
+
+func TestReturnMultipleValuesErrors(t *testing.T) {
+	inputs := []string{"return a, )", "return a, b, ;", "return 1, }"}
+
+	for _, input := range inputs {
+		p := New(lexer.New(input))
+		program := p.ParseProgram()
+
+		require.NotEmpty(t, p.Errors(), input)
+		for _, stmt := range program.Statements {
+			_, ok := stmt.(ast.ReturnStatement)
+			require.False(t, ok, input)
+		}
+	}
+}
+
 func checkParserErrors(t *testing.T, p *Parser) {
 	errors := p.Errors()
 	if len(errors) == 0 {
@@ -208,6 +311,279 @@ func checkParserErrors(t *testing.T, p *Parser) {
 	t.FailNow()
 }
 
+func TestEmptyProgram(t *testing.T) {
+	inputs := []string{"", "   \n\t", "\n\n"}
+
+	for _, input := range inputs {
+		l := lexer.New(input)
+		p := New(l)
+		program := p.ParseProgram()
+		checkParserErrors(t, p)
+
+		require.NotNil(t, program)
+		require.Equal(t, 0, len(program.Statements))
+		require.Equal(t, "", program.String())
+	}
+}
+
+func TestUnclosedBlocks(t *testing.T) {
+	testCases := []struct {
+		input         string
+		expectedError string
+	}{
+		{"if (true) { 1", "unclosed block: expected } to close the block opened on line 1"},
+		{"if (true) { 1 } else {\n2", "unclosed block: expected } to close the block opened on line 1"},
+		{"let f = fn(x) {\nx;\n", "unclosed block: expected } to close the block opened on line 1"},
+		{"1;\ndo { 2", "unclosed block: expected } to close the block opened on line 2"},
+	}
+
+	for _, tc := range testCases {
+		l := lexer.New(tc.input)
+		p := New(l)
+		p.ParseProgram()
+
+		require.Contains(t, p.Errors(), tc.expectedError)
+	}
+}
+
+func TestHashLiteralStringKeys(t *testing.T) {
+	input := `{name: 1, other + 1: 2}`
+
+	for _, stringKeys := range []bool{false, true} {
+		l := lexer.New(input)
+		p := New(l)
+		p.StringKeys = stringKeys
+		program := p.ParseProgram()
+		checkParserErrors(t, p)
+
+		stmt := program.Statements[0].(ast.ExpressionStatement)
+		hash, ok := stmt.Expression.(ast.HashLiteral)
+		require.True(t, ok)
+		require.Equal(t, 2, len(hash.Pairs))
+
+		for _, pair := range hash.Pairs {
+			switch key := pair.Key.(type) {
+			case ast.StringLiteral:
+				require.True(t, stringKeys)
+				require.Equal(t, "name", key.Value)
+			case ast.Identifier:
+				require.False(t, stringKeys)
+				require.Equal(t, "name", key.Value)
+			case ast.InfixExpression:
+				// only bare identifiers become strings
+				require.Equal(t, "(other + 1)", key.String())
+			default:
+				t.Fatalf("unexpected key %T", key)
+			}
+		}
+	}
+}
+
+func TestLogicalKeywordAliases(t *testing.T) {
+	testCases := []struct {
+		keywords string
+		symbols  string
+	}{
+		{"not true", "!true"},
+		{"a and b or c", "a && b || c"},
+		{"not a or not b and c", "!a || !b && c"},
+		{"a == b and not (c < d)", "a == b && !(c < d)"},
+	}
+
+	for _, tc := range testCases {
+		keywordsParser := New(lexer.New(tc.keywords))
+		keywordsProgram := keywordsParser.ParseProgram()
+		checkParserErrors(t, keywordsParser)
+
+		symbolsParser := New(lexer.New(tc.symbols))
+		symbolsProgram := symbolsParser.ParseProgram()
+		checkParserErrors(t, symbolsParser)
+
+		require.Equal(t, symbolsProgram.String(), keywordsProgram.String())
+	}
+}
+
+func TestWhileStatement(t *testing.T) {
+	input := `while (x < 10) { if (x == 5) { break; } continue }`
+
+	l := lexer.New(input)
+	p := New(l)
+	program := p.ParseProgram()
+	checkParserErrors(t, p)
+
+	require.Equal(t, 1, len(program.Statements))
+	stmt, ok := program.Statements[0].(ast.WhileStatement)
+	require.True(t, ok)
+	testInfixExpression(t, stmt.Condition, "x", "<", 10)
+
+	require.Equal(t, 2, len(stmt.Body.Statements))
+	ifStmt := stmt.Body.Statements[0].(ast.ExpressionStatement)
+	ifExp, ok := ifStmt.Expression.(ast.IfExpression)
+	require.True(t, ok)
+	_, ok = ifExp.Consequence.Statements[0].(ast.BreakStatement)
+	require.True(t, ok)
+
+	_, ok = stmt.Body.Statements[1].(ast.ContinueStatement)
+	require.True(t, ok)
+}
+
+func TestOptionalSemicolons(t *testing.T) {
+	testCases := []struct {
+		input              string
+		expectedStatements []string
+	}{
+		{"let x = 1\nlet y = 2", []string{"let x = 1;", "let y = 2;"}},
+		{"let x = 1\nx", []string{"let x = 1;", "x"}},
+		{"1\n2\n3", []string{"1", "2", "3"}},
+		{"return 1\n2", []string{"return 1;", "2"}},
+		{"let x = 1 let y = 2", []string{"let x = 1;", "let y = 2;"}},
+		{"x + 1 return x", []string{"(x + 1)", "return x;"}},
+		{"let x = 1; x", []string{"let x = 1;", "x"}},
+		{"let x = 1", []string{"let x = 1;"}},
+	}
+
+	for _, tc := range testCases {
+		l := lexer.New(tc.input)
+		p := New(l)
+		program := p.ParseProgram()
+		checkParserErrors(t, p)
+
+		require.Equal(t, len(tc.expectedStatements), len(program.Statements))
+		for i, stmt := range program.Statements {
+			require.Equal(t, tc.expectedStatements[i], stmt.String())
+		}
+	}
+}
+
+func TestTrailingSemicolons(t *testing.T) {
+	testCases := []struct {
+		input              string
+		expectedStatements []string
+	}{
+		{"if (x) { 1 };", []string{"if x 1"}},
+		{"if (x) { 1 } else { 2 }; 3", []string{"if x 1else 2", "3"}},
+		{"fn() {}();", []string{"fn() ()"}},
+		{"fn(x) { x }(1); fn() {}", []string{"fn(x) x(1)", "fn() "}},
+		{"let f = fn() {};", []string{"let f = fn() ;"}},
+		{"do { 1 }; 2", []string{"do { 1 }", "2"}},
+		{"while (x) {}; 1", []string{"whilex ", "1"}},
+		// stray semicolons are empty statements
+		{"fn() {}();;", []string{"fn() ()"}},
+		{";", []string{}},
+		{"1;;; 2", []string{"1", "2"}},
+		{"if (x) { 1;; };", []string{"if x 1"}},
+	}
+
+	for _, tc := range testCases {
+		l := lexer.New(tc.input)
+		p := New(l)
+		program := p.ParseProgram()
+		checkParserErrors(t, p)
+
+		require.Equal(t, len(tc.expectedStatements), len(program.Statements))
+		for i, stmt := range program.Statements {
+			require.Equal(t, tc.expectedStatements[i], stmt.String())
+		}
+	}
+}
+
+func TestAmbiguousStatementsOnOneLine(t *testing.T) {
+	testCases := []struct {
+		input         string
+		expectedError string
+	}{
+		{"1 2", `expected ; or newline before "2" on line 1`},
+		{"let x = 1 x", `expected ; or newline before "x" on line 1`},
+		{"fn() { 1 2 }", `expected ; or newline before "2" on line 1`},
+	}
+
+	for _, tc := range testCases {
+		l := lexer.New(tc.input)
+		p := New(l)
+		p.ParseProgram()
+
+		require.Equal(t, []string{tc.expectedError}, p.Errors())
+	}
+}
+
+func TestExpressionStatementWithoutPrefixParseFn(t *testing.T) {
+	input := "@"
+
+	l := lexer.New(input)
+	p := New(l)
+	program := p.ParseProgram()
+
+	require.Equal(t, []string{`illegal token "@" on line 1`}, p.Errors())
+	require.Equal(t, 0, len(program.Statements))
+
+	p = New(lexer.New(")"))
+	program = p.ParseProgram()
+
+	require.Equal(t, []string{"no prefix parse functions for ) found"}, p.Errors())
+	require.Equal(t, 0, len(program.Statements))
+}
+
+func TestRecoveryAtStatementBoundaries(t *testing.T) {
+	testCases := []struct {
+		input         string
+		expectedError string
+		expected      string
+	}{
+		{
+			"let a = 1; let b = ) + 2 * 3; let c = 3;",
+			"no prefix parse functions for ) found",
+			"let a = 1;let c = 3;",
+		},
+		{
+			"let a = 1;\nlet = 5 6 7\nreturn a;",
+			"expected next token to be IDENT, got = instead",
+			"let a = 1;return a;",
+		},
+		{
+			"let a = 1; @ a b c let c = 3; c",
+			`illegal token "@" on line 1`,
+			"let a = 1;let c = 3;c",
+		},
+		{
+			"let f = fn(1, 2) { 1 + };\nwhile (true) { break; }",
+			"expected next token to be IDENT, got INT instead",
+			"whiletrue break;",
+		},
+		{
+			"let a = [1, 2; if (a) { a }",
+			"expected next token to be ], got ; instead",
+			"if a a",
+		},
+	}
+
+	for _, tc := range testCases {
+		p := New(lexer.New(tc.input))
+		program := p.ParseProgram()
+
+		require.Equal(t, []string{tc.expectedError}, p.Errors(), tc.input)
+		require.Equal(t, tc.expected, program.String(), tc.input)
+	}
+}
+
+func TestIllegalTokenErrors(t *testing.T) {
+	testCases := []struct {
+		input         string
+		expectedError string
+	}{
+		{"let x = 0xZZ;", `illegal token "0xZZ" on line 1`},
+		{"let x = @;", `illegal token "@" on line 1`},
+		{"let s = \"unterminated", `illegal token "\"unterminated" on line 1`},
+		{"1 +\n é", `illegal token "é" on line 2`},
+	}
+
+	for _, tc := range testCases {
+		p := New(lexer.New(tc.input))
+		p.ParseProgram()
+
+		require.Contains(t, p.Errors(), tc.expectedError)
+	}
+}
+
 func TestIdentifierExpression(t *testing.T) {
 	input := "foobar;"
 
@@ -289,6 +665,37 @@ func TestFloatExpressions(t *testing.T) {
 	require.Equal(t, "5234.23234413", literal.TokenLiteral())
 }
 
+func TestFloatLiteralForms(t *testing.T) {
+	testCases := []struct {
+		input    string
+		expected float64
+	}{
+		{".5", 0.5},
+		{"0.5", 0.5},
+		{"5.", 5},
+		{"5.0", 5},
+		{"1.5e-3", 0.0015},
+		{"2e3", 2000},
+		{".5E1", 5},
+	}
+
+	for _, tc := range testCases {
+		l := lexer.New(tc.input)
+		p := New(l)
+		program := p.ParseProgram()
+		checkParserErrors(t, p)
+
+		require.Equal(t, 1, len(program.Statements))
+		stmt, ok := program.Statements[0].(ast.ExpressionStatement)
+		require.True(t, ok)
+
+		literal, ok := stmt.Expression.(ast.FloatLiteral)
+		require.True(t, ok)
+		require.Equal(t, tc.expected, literal.Value)
+		require.Equal(t, tc.input, literal.TokenLiteral())
+	}
+}
+
 func TestParsingPrefixExpressions(t *testing.T) {
 	prefixTests := []struct {
 		input    string
@@ -301,6 +708,9 @@ func TestParsingPrefixExpressions(t *testing.T) {
 		{"-foobar;", "-", "foobar"},
 		{"!true;", "!", true},
 		{"!false;", "!", false},
+		{"+5;", "+", 5},
+		{"+5.5;", "+", 5.5},
+		{"+true;", "+", true},
 	}
 	for _, tc := range prefixTests {
 		l := lexer.New(tc.input)
@@ -482,6 +892,34 @@ func TestOperatorPrecedenceParsing(t *testing.T) {
 			"5 < 4 != 3 > 4",
 			"((5 < 4) != (3 > 4))",
 		},
+		{
+			"5 <= 4 == 3 >= 4",
+			"((5 <= 4) == (3 >= 4))",
+		},
+		{
+			"1 + 2 >= 3",
+			"((1 + 2) >= 3)",
+		},
+		{
+			"a || b && c",
+			"(a || (b && c))",
+		},
+		{
+			"a == b && c < d || e",
+			"(((a == b) && (c < d)) || e)",
+		},
+		{
+			"a ?? b || c",
+			"(a ?? (b || c))",
+		},
+		{
+			"a ?? b ?? c + 1",
+			"((a ?? b) ?? (c + 1))",
+		},
+		{
+			"x[0] = a ?? b",
+			"((x[0]) = (a ?? b))",
+		},
 		{
 			"3 + 4 * 5 == 3 * 1 + 4 * 5",
 			"((3 + (4 * 5)) == ((3 * 1) + (4 * 5)))",
@@ -518,6 +956,14 @@ func TestOperatorPrecedenceParsing(t *testing.T) {
 			"add(a * b[2], b[1], 2 * [1, 2][1])",
 			"add((a * (b[2])), (b[1]), (2 * ([1, 2][1])))",
 		},
+		{
+			"a[0] = b + 1 * c",
+			"((a[0]) = (b + (1 * c)))",
+		},
+		{
+			"a[0] = b[1] = 2",
+			"((a[0]) = ((b[1]) = 2))",
+		},
 	}
 
 	for _, tc := range tests {
@@ -589,6 +1035,68 @@ func TestIfElseExpression(t *testing.T) {
 	testIdentifier(t, alternative.Expression, "y")
 }
 
+func TestElseIfExpression(t *testing.T) {
+	input := `if (x < y) { x } else if (x > y) { y } else { z }`
+	l := lexer.New(input)
+	p := New(l)
+	program := p.ParseProgram()
+	checkParserErrors(t, p)
+
+	require.Equal(t, 1, len(program.Statements))
+	stmt, ok := program.Statements[0].(ast.ExpressionStatement)
+	require.True(t, ok)
+
+	exp, ok := stmt.Expression.(ast.IfExpression)
+	require.True(t, ok)
+	testInfixExpression(t, exp.Condition, "x", "<", "y")
+
+	consequence, ok := exp.Consequence.Statements[0].(ast.ExpressionStatement)
+	require.True(t, ok)
+	testIdentifier(t, consequence.Expression, "x")
+
+	// the else if is nested as the only statement of the alternative
+	require.Equal(t, 1, len(exp.Alternative.Statements))
+	alternative, ok := exp.Alternative.Statements[0].(ast.ExpressionStatement)
+	require.True(t, ok)
+
+	nested, ok := alternative.Expression.(ast.IfExpression)
+	require.True(t, ok)
+	testInfixExpression(t, nested.Condition, "x", ">", "y")
+
+	consequence, ok = nested.Consequence.Statements[0].(ast.ExpressionStatement)
+	require.True(t, ok)
+	testIdentifier(t, consequence.Expression, "y")
+
+	alternative, ok = nested.Alternative.Statements[0].(ast.ExpressionStatement)
+	require.True(t, ok)
+	testIdentifier(t, alternative.Expression, "z")
+
+	require.Equal(t, "if (x < y) xelse if (x > y) yelse z", program.String())
+}
+
+func TestDoExpression(t *testing.T) {
+	input := `let x = do { let a = 2; a * 3 };`
+
+	l := lexer.New(input)
+	p := New(l)
+	program := p.ParseProgram()
+	checkParserErrors(t, p)
+	require.Equal(t, 1, len(program.Statements))
+
+	stmt, ok := program.Statements[0].(ast.LetStatement)
+	require.True(t, ok)
+
+	exp, ok := stmt.Value.(ast.DoExpression)
+	require.True(t, ok)
+	require.Equal(t, 2, len(exp.Body.Statements))
+
+	testLetStatement(t, exp.Body.Statements[0], "a")
+
+	last, ok := exp.Body.Statements[1].(ast.ExpressionStatement)
+	require.True(t, ok)
+	testInfixExpression(t, last.Expression, "a", "*", 3)
+}
+
 // function for testing the parsing of the function's parameters
 func TestFunctionParameterParsing(t *testing.T) {
 	tests := []struct {
@@ -611,6 +1119,8 @@ func TestFunctionParameterParsing(t *testing.T) {
 		function, ok := stmt.Expression.(ast.FunctionLiteral)
 		require.True(t, ok)
 
+		// an empty parameter list is empty, not nil
+		require.NotNil(t, function.Parameters)
 		require.Equal(t, len(tc.expectedParams), len(function.Parameters))
 
 		for i, ident := range tc.expectedParams {
@@ -619,6 +1129,189 @@ func TestFunctionParameterParsing(t *testing.T) {
 	}
 }
 
+func TestInvalidFunctionParameters(t *testing.T) {
+	testCases := []struct {
+		input         string
+		expectedError string
+	}{
+		{"fn(1) {}", "expected next token to be IDENT, got INT instead"},
+		{"fn(x, ) {}", "expected next token to be IDENT, got ) instead"},
+		{"fn(x y) {}", "expected next token to be ), got IDENT instead"},
+		{"fn(x, \"y\") {}", "expected next token to be IDENT, got STRING instead"},
+	}
+
+	for _, tc := range testCases {
+		l := lexer.New(tc.input)
+		p := New(l)
+		p.ParseProgram()
+
+		require.Contains(t, p.Errors(), tc.expectedError)
+	}
+}
+
+func TestComments(t *testing.T) {
+	testCases := []struct {
+		withComments string
+		without      string
+	}{
+		{"1 + /* c */ 2", "1 + 2"},
+		{"let /*x*/ y = 3", "let y = 3"},
+		{"let y = 3; // trailing", "let y = 3;"},
+		{"// leading\nlet y = 3", "let y = 3"},
+		{"fn(/* none */) { /* empty */ }", "fn() { }"},
+		{"add(1, // first\n2)", "add(1, 2)"},
+		{"[1, /* a\nb */ 2]", "[1, 2]"},
+		{"a /**/ * b", "a * b"},
+	}
+
+	for _, tc := range testCases {
+		p := New(lexer.New(tc.withComments))
+		program := p.ParseProgram()
+		checkParserErrors(t, p)
+
+		expected := New(lexer.New(tc.without)).ParseProgram()
+		require.Equal(t, expected.String(), program.String(), tc.withComments)
+	}
+}
+
+func TestCommentLines(t *testing.T) {
+	input := `// header
+/* block
+   comment */ let x = 1
+// between
+let y = x /* same line */ + 1`
+
+	p := New(lexer.New(input))
+	program := p.ParseProgram()
+	checkParserErrors(t, p)
+
+	require.Equal(t, 2, len(program.Statements))
+	require.Equal(t, 3, program.Statements[0].(ast.LetStatement).Token.Line)
+	require.Equal(t, 5, program.Statements[1].(ast.LetStatement).Token.Line)
+
+	// a comment doesn't separate statements on the same line
+	p = New(lexer.New("1 /* c */ 2"))
+	p.ParseProgram()
+	require.Contains(t, p.Errors(), `expected ; or newline before "2" on line 1`)
+
+	// but a newline inside it does
+	p = New(lexer.New("1 /* c\n */ 2"))
+	program = p.ParseProgram()
+	checkParserErrors(t, p)
+	require.Equal(t, 2, len(program.Statements))
+}
+
+func TestInvalidAssignmentTargets(t *testing.T) {
+	testCases := []struct {
+		input         string
+		expectedError string
+	}{
+		{"x = 1", "invalid assignment target: x"},
+		{"f() = 1", "invalid assignment target: f()"},
+		{"1 + a[0] = 2", "invalid assignment target: (1 + (a[0]))"},
+	}
+
+	for _, tc := range testCases {
+		l := lexer.New(tc.input)
+		p := New(l)
+		p.ParseProgram()
+
+		require.Contains(t, p.Errors(), tc.expectedError)
+	}
+}
+
+func TestLambdaParsing(t *testing.T) {
+	testCases := []struct {
+		lambda     string
+		equivalent string
+	}{
+		{`\(x, y) -> x + y`, "fn(x, y) { x + y }"},
+		{`\() -> 1`, "fn() { 1 }"},
+		{`\(x) -> \(y) -> x * y`, "fn(x) { fn(y) { x * y } }"},
+		{`apply(\(a, b) -> a + b, [1, 2])`, "apply(fn(a, b) { a + b }, [1, 2])"},
+		{`let inc = \(x) -> x + 1;`, "let inc = fn(x) { x + 1 };"},
+	}
+
+	for _, tc := range testCases {
+		l := lexer.New(tc.lambda)
+		p := New(l)
+		program := p.ParseProgram()
+		checkParserErrors(t, p)
+
+		l = lexer.New(tc.equivalent)
+		p = New(l)
+		expected := p.ParseProgram()
+		checkParserErrors(t, p)
+
+		require.Equal(t, expected.String(), program.String())
+	}
+
+	program := New(lexer.New(`\(x, y) -> x + y`)).ParseProgram()
+	function, ok := program.Statements[0].(ast.ExpressionStatement).Expression.(ast.FunctionLiteral)
+	require.True(t, ok)
+	require.Equal(t, 2, len(function.Parameters))
+	require.Equal(t, 1, len(function.Body.Statements))
+	body := function.Body.Statements[0].(ast.ExpressionStatement)
+	testInfixExpression(t, body.Expression, "x", "+", "y")
+}
+
+func TestExpressionBodiedFunctions(t *testing.T) {
+	testCases := []struct {
+		input      string
+		equivalent string
+	}{
+		{"fn(x) => x + 1", "fn(x) { x + 1 }"},
+		{"fn() => 1", "fn() { 1 }"},
+		{"fn(x) => fn(y) => x * y", "fn(x) { fn(y) { x * y } }"},
+		{"fn(x) => if (x) { 1 } else { 2 }", "fn(x) { if (x) { 1 } else { 2 } }"},
+		{"apply(fn(a, b) => a + b, [1, 2])", "apply(fn(a, b) { a + b }, [1, 2])"},
+		{"let inc = fn(x) => x + 1; inc(2)", "let inc = fn(x) { x + 1 }; inc(2)"},
+	}
+
+	for _, tc := range testCases {
+		p := New(lexer.New(tc.input))
+		program := p.ParseProgram()
+		checkParserErrors(t, p)
+
+		p = New(lexer.New(tc.equivalent))
+		expected := p.ParseProgram()
+		checkParserErrors(t, p)
+
+		require.Equal(t, expected.String(), program.String(), tc.input)
+	}
+
+	program := New(lexer.New("fn(x, y) => x + y")).ParseProgram()
+	function, ok := program.Statements[0].(ast.ExpressionStatement).Expression.(ast.FunctionLiteral)
+	require.True(t, ok)
+	require.Equal(t, 2, len(function.Parameters))
+	require.Equal(t, 1, len(function.Body.Statements))
+	body := function.Body.Statements[0].(ast.ExpressionStatement)
+	testInfixExpression(t, body.Expression, "x", "+", "y")
+
+	p := New(lexer.New("fn(x) =>"))
+	p.ParseProgram()
+	require.Contains(t, p.Errors(), "no prefix parse functions for EOF found")
+}
+
+func TestInvalidLambdas(t *testing.T) {
+	testCases := []struct {
+		input         string
+		expectedError string
+	}{
+		{`\x -> x`, "expected next token to be (, got IDENT instead"},
+		{`\(x) x`, "expected next token to be ->, got IDENT instead"},
+		{`\(x) ->`, "no prefix parse functions for EOF found"},
+	}
+
+	for _, tc := range testCases {
+		l := lexer.New(tc.input)
+		p := New(l)
+		p.ParseProgram()
+
+		require.Contains(t, p.Errors(), tc.expectedError)
+	}
+}
+
 // function for testing the parsing of functions
 func TestFunctionLiteralParsing(t *testing.T) {
 	input := `fn(x, y) { x + y; }`
@@ -671,6 +1364,53 @@ func TestCallExpressionParsing(t *testing.T) {
 	testInfixExpression(t, exp.Arguments[2], 4, "+", 5)
 }
 
+func TestCallAndIndexChaining(t *testing.T) {
+	parseExpression := func(input string) ast.Expression {
+		p := New(lexer.New(input))
+		program := p.ParseProgram()
+		checkParserErrors(t, p)
+		require.Equal(t, 1, len(program.Statements))
+
+		stmt, ok := program.Statements[0].(ast.ExpressionStatement)
+		require.True(t, ok)
+		return stmt.Expression
+	}
+
+	// getArray()[0] indexes the result of the call
+	index, ok := parseExpression("getArray()[0]").(ast.IndexExpression)
+	require.True(t, ok)
+	testLiteralExpression(t, index.Index, 0)
+	call, ok := index.Left.(ast.CallExpression)
+	require.True(t, ok)
+	testIdentifier(t, call.Function, "getArray")
+	require.Equal(t, 0, len(call.Arguments))
+
+	// arr[0](x) calls the element
+	call, ok = parseExpression("arr[0](x)").(ast.CallExpression)
+	require.True(t, ok)
+	require.Equal(t, 1, len(call.Arguments))
+	testIdentifier(t, call.Arguments[0], "x")
+	index, ok = call.Function.(ast.IndexExpression)
+	require.True(t, ok)
+	testIdentifier(t, index.Left, "arr")
+	testLiteralExpression(t, index.Index, 0)
+
+	testCases := []struct {
+		input    string
+		expected string
+	}{
+		{"f(1)[0]", "(f(1)[0])"},
+		{"f(1)(2)", "f(1)(2)"},
+		{"arr[0][1](x)(y)[2]", "(((arr[0])[1])(x)(y)[2])"},
+		{"-f(1)[0]", "(-(f(1)[0]))"},
+		{"a[0](1) * b[1](2)", "((a[0])(1) * (b[1])(2))"},
+	}
+
+	for _, tc := range testCases {
+		require.Equal(t, tc.expected, parseExpression(tc.input).String())
+	}
+}
+
 // function for testing the parsing of arguments in a call
 func TestCallExpressionParameterParsing(t *testing.T) {
 	tests := []struct {
@@ -714,3 +1454,24 @@ func TestCallExpressionParameterParsing(t *testing.T) {
 		}
 	}
 }
+
+func TestHashLiteralsWithUnhashableKeyNodes(t *testing.T) {
+	// function and array literals can't be keys of a go map, pairs are
+	// kept in source order instead
+	input := `{fn(x) { x }: 1, [1, 2]: 2, "a": 3}`
+	l := lexer.New(input)
+	p := New(l)
+	program := p.ParseProgram()
+	checkParserErrors(t, p)
+
+	stmt := program.Statements[0].(ast.ExpressionStatement)
+	hash, ok := stmt.Expression.(ast.HashLiteral)
+	require.True(t, ok)
+	require.Equal(t, 3, len(hash.Pairs))
+
+	_, ok = hash.Pairs[0].Key.(ast.FunctionLiteral)
+	require.True(t, ok)
+	_, ok = hash.Pairs[1].Key.(ast.ArrayLiteral)
+	require.True(t, ok)
+	require.Equal(t, `{fn(x) x:1, [1, 2]:2, a:3}`, hash.String())
+}
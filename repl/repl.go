@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"io"
 
+	"github.com/stevensopilidis/monkey/ast"
 	"github.com/stevensopilidis/monkey/compiler"
 	"github.com/stevensopilidis/monkey/lexer"
 	"github.com/stevensopilidis/monkey/object"
@@ -26,7 +27,7 @@ func Start(in io.Reader, out io.Writer) {
 	}
 
 	for {
-		fmt.Print(out, PROMPT)
+		fmt.Fprint(out, PROMPT)
 
 		scanned := scanner.Scan()
 		if !scanned {
@@ -62,11 +63,31 @@ func Start(in io.Reader, out io.Writer) {
 		}
 
 		lastPopped := machine.LastPoppedStackElement()
-		io.WriteString(out, lastPopped.Inspect())
+		if lastPopped == nil || !producesValue(program) {
+			continue
+		}
+
+		io.WriteString(out, object.ReplString(lastPopped))
 		io.WriteString(out, "\n")
 	}
 }
 
+// function for checking whether the program leaves a value behind, only
+// a trailing expression or return statement does, anything else (e.g. a let)
+// would leave the stale value of an earlier statement as the last popped
+func producesValue(program *ast.Program) bool {
+	if len(program.Statements) == 0 {
+		return false
+	}
+
+	switch program.Statements[len(program.Statements)-1].(type) {
+	case ast.ExpressionStatement, ast.ReturnStatement:
+		return true
+	default:
+		return false
+	}
+}
+
 func printParserErrors(out io.Writer, errors []string) {
 	for _, msg := range errors {
 		io.WriteString(out, "\t"+msg+"\n")
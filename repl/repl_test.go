@@ -0,0 +1,30 @@
+package repl
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestStart(t *testing.T) {
+	testCases := []struct {
+		input    string
+		expected string
+	}{
+		{"", PROMPT},
+		{"\n", PROMPT + PROMPT},
+		{"   \n1 + 2\n", PROMPT + PROMPT + "3\n" + PROMPT},
+		{"let x = 1\n", PROMPT + PROMPT},
+		{"2; let x = 1\n", PROMPT + PROMPT},
+		{"let x = 1\nx\n", PROMPT + PROMPT + "1\n" + PROMPT},
+		{"return 5\n", PROMPT + "5\n" + PROMPT},
+	}
+
+	for _, tc := range testCases {
+		var out bytes.Buffer
+		Start(strings.NewReader(tc.input), &out)
+		require.Equal(t, tc.expected, out.String())
+	}
+}
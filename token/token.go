@@ -5,6 +5,7 @@ type TokenType string
 type Token struct {
 	Type    TokenType // type of token
 	Literal string    // value of token
+	Line    int       // line of the source code the token starts at
 }
 
 // available TokenTypes
@@ -26,16 +27,32 @@ const (
 	ASTERISK = "*"
 	SLASH    = "/"
 
-	LT = "<"
-	GT = ">"
+	LT    = "<"
+	GT    = ">"
+	LT_EQ = "<="
+	GT_EQ = ">="
 
 	EQ     = "=="
 	NOT_EQ = "!="
 
+	AND = "&&"
+	OR  = "||"
+
+	// nil-coalescing (e.g h["key"] ?? default)
+	NULLISH = "??"
+
+	// lambda shorthand (e.g \(x) -> x + 1)
+	BACKSLASH = "\\"
+	ARROW     = "->"
+
+	// expression bodied functions (e.g fn(x) => x + 1)
+	FAT_ARROW = "=>"
+
 	// Delimiters
 	COMMA     = ","
 	SEMICOLON = ";"
 	COLON     = ":"
+	ELLIPSIS  = "..."
 
 	LPAREN   = "("
 	RPAREN   = ")"
@@ -52,17 +69,29 @@ const (
 	IF       = "IF"
 	ELSE     = "ELSE"
 	RETURN   = "RETURN"
+	DO       = "DO"
+	WHILE    = "WHILE"
+	BREAK    = "BREAK"
+	CONTINUE = "CONTINUE"
 )
 
 // map of language keywords
 var keywords = map[string]TokenType{
-	"fn":     FUNCTION,
-	"let":    LET,
-	"true":   TRUE,
-	"false":  FALSE,
-	"if":     IF,
-	"else":   ELSE,
-	"return": RETURN,
+	"fn":       FUNCTION,
+	"let":      LET,
+	"true":     TRUE,
+	"false":    FALSE,
+	"if":       IF,
+	"else":     ELSE,
+	"return":   RETURN,
+	"do":       DO,
+	"while":    WHILE,
+	"break":    BREAK,
+	"continue": CONTINUE,
+	// keyword aliases of the logical operators
+	"not": BANG,
+	"and": AND,
+	"or":  OR,
 }
 
 // function that returns TokenType of identifier
@@ -2,6 +2,7 @@ package vm
 
 import (
 	"fmt"
+	"strings"
 
 	"github.com/stevensopilidis/monkey/code"
 	"github.com/stevensopilidis/monkey/compiler"
@@ -9,11 +10,11 @@ import (
 )
 
 // global instances of true and false
-var True = &object.Boolean{Value: true}
-var False = &object.Boolean{Value: false}
+var True = object.TRUE
+var False = object.FALSE
 
 // global instance of NULL
-var Null = &object.Null{}
+var Null = object.NULL
 
 const (
 	StackSize   = 2048
@@ -24,7 +25,9 @@ const (
 // stack frame
 type Frame struct {
 	fn *object.CompiledFunction
-	ip int
+	// closure being executed, nil for functions that capture nothing
+	closure *object.Closure
+	ip      int
 	// will keep track of the stack pointer before executing function and then restores
 	// it after executing it
 	basePointer int
@@ -49,6 +52,12 @@ type VM struct {
 	framesIndex int             // current frame being executed
 	sp          int             // stack pointer
 	globals     []object.Object // stores global variables
+
+	// debug mode that verifies that the stack is empty after each
+	// top-level statement and that no frame pops below its base pointer,
+	// catching compiler push/pop mismatches
+	CheckStack    bool
+	statementEnds map[int]bool
 }
 
 func (vm *VM) currentFrame() *Frame {
@@ -67,19 +76,25 @@ func (vm *VM) popFrame() *Frame {
 
 func New(byteCode *compiler.Bytecode) *VM {
 	// construct frame for main program
-	mainFn := &object.CompiledFunction{Instructions: byteCode.Instructions}
+	mainFn := &object.CompiledFunction{Instructions: byteCode.Instructions, Name: "main"}
 	mainFrame := NewFrame(mainFn, 0)
 
 	frames := make([]*Frame, MaxFrames)
 	frames[0] = mainFrame
 
+	statementEnds := make(map[int]bool)
+	for _, end := range byteCode.StatementEnds {
+		statementEnds[end] = true
+	}
+
 	return &VM{
-		constants:   byteCode.Constants,
-		stack:       make([]object.Object, StackSize),
-		frames:      frames,
-		framesIndex: 1,
-		sp:          0,
-		globals:     make([]object.Object, GlobalsSize),
+		constants:     byteCode.Constants,
+		stack:         make([]object.Object, StackSize),
+		frames:        frames,
+		framesIndex:   1,
+		sp:            0,
+		globals:       make([]object.Object, GlobalsSize),
+		statementEnds: statementEnds,
 	}
 }
 
@@ -89,6 +104,23 @@ func NewWithGlobalsStore(bytecode *compiler.Bytecode, s []object.Object) *VM {
 	return vm
 }
 
+// function that returns the global variables of the vm indexed like
+// the globals of the compiler's symbol table
+func (vm *VM) Globals() []object.Object {
+	return vm.globals
+}
+
+// function for seeding the global variable at index so a host program can
+// pass values to a script
+func (vm *VM) SetGlobal(index int, obj object.Object) error {
+	if index < 0 || index >= len(vm.globals) {
+		return fmt.Errorf("global index out of range: %d", index)
+	}
+
+	vm.globals[index] = obj
+	return nil
+}
+
 func (vm *VM) StackTop() object.Object {
 	if vm.sp == 0 {
 		return nil
@@ -97,7 +129,49 @@ func (vm *VM) StackTop() object.Object {
 	return vm.stack[vm.sp-1]
 }
 
+// error returned by the vm while executing bytecode
+// along with the call stack at the time of the error
+type RuntimeError struct {
+	Err   error
+	Stack []string
+}
+
+func (e *RuntimeError) Error() string {
+	var out strings.Builder
+	out.WriteString(e.Err.Error())
+	for _, name := range e.Stack {
+		out.WriteString("\n\tat " + name)
+	}
+	return out.String()
+}
+
+func (e *RuntimeError) Unwrap() error {
+	return e.Err
+}
+
+// function that returns the names of the active frames
+// starting from the innermost one
+func (vm *VM) CallStack() []string {
+	stack := make([]string, 0, vm.framesIndex)
+	for i := vm.framesIndex - 1; i >= 0; i-- {
+		name := vm.frames[i].fn.Name
+		if name == "" {
+			name = "<anonymous>"
+		}
+		stack = append(stack, name)
+	}
+	return stack
+}
+
 func (vm *VM) Run() error {
+	err := vm.run()
+	if err != nil {
+		return &RuntimeError{Err: err, Stack: vm.CallStack()}
+	}
+	return nil
+}
+
+func (vm *VM) run() error {
 	var ip int
 	var instructions code.Instructions
 	var op code.Opcode
@@ -135,7 +209,7 @@ func (vm *VM) Run() error {
 			if err != nil {
 				return err
 			}
-		case code.OpEqual, code.OpNotEqual, code.OpGreaterThan:
+		case code.OpEqual, code.OpNotEqual, code.OpGreaterThan, code.OpGreaterEqual:
 			err := vm.executeComparison(op)
 			if err != nil {
 				return err
@@ -158,9 +232,22 @@ func (vm *VM) Run() error {
 			vm.currentFrame().ip += 2 // skip the two bytes of address
 
 			condition := vm.pop()
-			if !isTruthy(condition) {
+			if !object.IsTruthy(condition) {
 				vm.currentFrame().ip = pos - 1
 			}
+		case code.OpJumpTruthy:
+			pos := int(code.ReadUint16(instructions[ip+1:]))
+			vm.currentFrame().ip += 2 // skip the two bytes of address
+
+			condition := vm.pop()
+			if object.IsTruthy(condition) {
+				vm.currentFrame().ip = pos - 1
+			}
+		case code.OpDup:
+			err := vm.push(vm.stack[vm.sp-1])
+			if err != nil {
+				return err
+			}
 		case code.OpNull:
 			err := vm.push(Null)
 			if err != nil {
@@ -175,8 +262,15 @@ func (vm *VM) Run() error {
 		case code.OpGetGlobal:
 			globalIndex := code.ReadUint16(instructions[ip+1:])
 			vm.currentFrame().ip += 2
+			// a global is defined at compile time but only assigned once
+			// its let runs, which may never happen if the let failed or
+			// was skipped (e.g in an earlier repl line)
+			value := vm.globals[globalIndex]
+			if value == nil {
+				return fmt.Errorf("use of unassigned global")
+			}
 			// push the identifiers value into the stack
-			err := vm.push(vm.globals[globalIndex])
+			err := vm.push(value)
 			if err != nil {
 				return err
 			}
@@ -196,17 +290,18 @@ func (vm *VM) Run() error {
 			vm.currentFrame().ip += 2
 
 			hash, err := vm.buildHash(vm.sp-numElements, vm.sp)
+			// the keys and values are dropped even when the hash can't be
+			// built so the stack is left balanced
+			vm.sp -= numElements
 			if err != nil {
 				return err
 			}
 
-			vm.sp -= numElements
 			err = vm.push(hash)
 			if err != nil {
 				return err
 			}
 		case code.OpIndex:
-			fmt.Println("----> INDEX")
 			left := vm.pop()
 			index := vm.pop()
 
@@ -214,18 +309,42 @@ func (vm *VM) Run() error {
 			if err != nil {
 				return err
 			}
+		case code.OpSetIndex:
+			value := vm.pop()
+			index := vm.pop()
+			left := vm.pop()
+
+			err := vm.executeSetIndex(left, index, value)
+			if err != nil {
+				return err
+			}
 		case code.OpCall:
 			numArgs := code.ReadUint8(instructions[ip+1:])
 
 			vm.currentFrame().ip += 1
 			err := vm.executeCall(int(numArgs))
 
+			if err != nil {
+				return err
+			}
+		case code.OpTailCall:
+			numArgs := code.ReadUint8(instructions[ip+1:])
+
+			vm.currentFrame().ip += 1
+			err := vm.executeTailCall(int(numArgs))
+
 			if err != nil {
 				return err
 			}
 		case code.OpReturnValue:
 			returnValue := vm.pop()
 
+			// returning from the main frame ends the program with the
+			// returned value as the last popped element
+			if vm.framesIndex == 1 {
+				return nil
+			}
+
 			frame := vm.popFrame()
 			// go back to the return address address in the stack
 			vm.sp = frame.basePointer - 1
@@ -235,6 +354,15 @@ func (vm *VM) Run() error {
 				return err
 			}
 		case code.OpReturn:
+			if vm.framesIndex == 1 {
+				err := vm.push(Null)
+				if err != nil {
+					return err
+				}
+				vm.pop()
+				return nil
+			}
+
 			frame := vm.popFrame()
 			// go back to the return address address in the stack
 			vm.sp = frame.basePointer - 1
@@ -270,24 +398,115 @@ func (vm *VM) Run() error {
 			if err != nil {
 				return err
 			}
+		case code.OpClosure:
+			constIndex := code.ReadUint16(instructions[ip+1:])
+			numFree := code.ReadUint8(instructions[ip+3:])
+			vm.currentFrame().ip += 3
+
+			err := vm.pushClosure(int(constIndex), int(numFree))
+			if err != nil {
+				return err
+			}
+		case code.OpGetFree:
+			freeIndex := code.ReadUint8(instructions[ip+1:])
+			vm.currentFrame().ip += 1
+
+			err := vm.push(vm.currentFrame().closure.Free[freeIndex])
+			if err != nil {
+				return err
+			}
+		case code.OpCurrentClosure:
+			// a function that captures nothing runs without a closure and
+			// is called through its compiled function
+			frame := vm.currentFrame()
+			var current object.Object = frame.fn
+			if frame.closure != nil {
+				current = frame.closure
+			}
+
+			err := vm.push(current)
+			if err != nil {
+				return err
+			}
+		}
+
+		if vm.CheckStack {
+			err := vm.checkStack()
+			if err != nil {
+				return err
+			}
 		}
 	}
 
 	return nil
 }
 
+// function that verifies the stack is empty at the end of a top-level
+// statement and that the current frame hasn't popped into the stack of
+// its caller
+func (vm *VM) checkStack() error {
+	frame := vm.currentFrame()
+	if vm.sp < frame.basePointer {
+		return fmt.Errorf("stack underflow in %s at %d: sp=%d, base pointer=%d",
+			frame.fn.Name, frame.ip, vm.sp, frame.basePointer)
+	}
+
+	if vm.framesIndex != 1 || !vm.statementEnds[frame.ip+1] {
+		return nil
+	}
+
+	if vm.sp != 0 {
+		return fmt.Errorf("stack imbalance after statement ending at %d: sp=%d, want=0",
+			frame.ip+1, vm.sp)
+	}
+
+	return nil
+}
+
 func (vm *VM) executeCall(numArgs int) error {
 	callee := vm.stack[vm.sp-1-numArgs]
+	// the number of arguments is validated here, before a frame is set up,
+	// for every kind of callee
 	switch callee := callee.(type) {
 	case *object.CompiledFunction:
+		err := checkArity(callee.NumParameters, numArgs)
+		if err != nil {
+			return err
+		}
 		return vm.callFunction(callee, numArgs)
+	case *object.Closure:
+		err := checkArity(callee.Fn.NumParameters, numArgs)
+		if err != nil {
+			return err
+		}
+		return vm.callClosure(callee, numArgs)
 	case *object.Builtin:
+		// builtins are variadic and validate their own arguments
 		return vm.callBuiltin(callee, numArgs)
 	default:
 		return fmt.Errorf("calling non-function and non-built-in")
 	}
 }
 
+// function for executing a call in tail position, when the callee is the
+// function of the current frame its arguments replace the current ones and
+// the frame is restarted, otherwise it's a regular call
+func (vm *VM) executeTailCall(numArgs int) error {
+	frame := vm.currentFrame()
+	callee := vm.stack[vm.sp-1-numArgs]
+
+	fn, ok := callee.(*object.CompiledFunction)
+	if !ok || fn != frame.fn || fn.NumParameters != numArgs {
+		return vm.executeCall(numArgs)
+	}
+
+	copy(vm.stack[frame.basePointer:], vm.stack[vm.sp-numArgs:vm.sp])
+	vm.sp = frame.basePointer + fn.NumLocals
+	frame.ip = -1
+
+	return nil
+}
+
 func (vm *VM) callBuiltin(builtin *object.Builtin, numArgs int) error {
 	args := vm.stack[vm.sp-numArgs : vm.sp]
 
@@ -302,10 +521,18 @@ func (vm *VM) callBuiltin(builtin *object.Builtin, numArgs int) error {
 	return nil
 }
 
-func (vm *VM) callFunction(fn *object.CompiledFunction, numArgs int) error {
-	if fn.NumParameters != numArgs {
+// function for checking that a callee expecting want arguments got them
+func checkArity(want, got int) error {
+	if want != got {
 		return fmt.Errorf("wrong number of arguments: want=%d, got=%d",
-			fn.NumParameters, numArgs)
+			want, got)
+	}
+	return nil
+}
+
+func (vm *VM) callFunction(fn *object.CompiledFunction, numArgs int) error {
+	if vm.framesIndex >= MaxFrames {
+		return fmt.Errorf("frame overflow")
 	}
 
 	// make sure to include the arguments as local bindings
@@ -320,15 +547,43 @@ func (vm *VM) callFunction(fn *object.CompiledFunction, numArgs int) error {
 	return nil
 }
 
+// function for calling a closure, its frame keeps the closure so the
+// function can read the free variables it captured
+func (vm *VM) callClosure(cl *object.Closure, numArgs int) error {
+	err := vm.callFunction(cl.Fn, numArgs)
+	if err != nil {
+		return err
+	}
+
+	vm.currentFrame().closure = cl
+	return nil
+}
+
+// function for creating a closure of the compiled function at constIndex
+// of the constant pool, capturing the numFree values on top of the stack
+func (vm *VM) pushClosure(constIndex, numFree int) error {
+	fn, ok := vm.constants[constIndex].(*object.CompiledFunction)
+	if !ok {
+		return fmt.Errorf("not a function: %s", vm.constants[constIndex].Type().Display())
+	}
+
+	free := make([]object.Object, numFree)
+	copy(free, vm.stack[vm.sp-numFree:vm.sp])
+	vm.sp = vm.sp - numFree
+
+	return vm.push(&object.Closure{Fn: fn, Free: free})
+}
+
 func (vm *VM) executeIndexExpression(left, index object.Object) error {
-	fmt.Println("-----> ", left.Type())
 	switch {
 	case left.Type() == object.ARRAY_OBJ && index.Type() == object.INTEGER_OBJ:
 		return vm.executeArrayIndex(left, index)
 	case left.Type() == object.HASH_OBJ:
 		return vm.executeHashIndex(left, index)
+	case left.Type() == object.STRING_OBJ && index.Type() == object.INTEGER_OBJ:
+		return vm.executeStringIndex(left, index)
 	default:
-		return fmt.Errorf("index operator not supported: %s", left.Type())
+		return fmt.Errorf("index operator not supported: %s", left.Type().Display())
 	}
 }
 
@@ -344,15 +599,24 @@ func (vm *VM) executeArrayIndex(array, index object.Object) error {
 	return vm.push(arrayObject.Elements[i])
 }
 
+func (vm *VM) executeStringIndex(str, index object.Object) error {
+	char, ok := object.CharAt(str.(*object.String).Value, index.(*object.Integer).Value)
+	if !ok {
+		return vm.push(Null)
+	}
+
+	return vm.push(&object.String{Value: char})
+}
+
 func (vm *VM) executeHashIndex(hash, index object.Object) error {
 	hashObject := hash.(*object.Hash)
 
 	key, ok := index.(object.Hashable)
 	if !ok {
-		return fmt.Errorf("unusable as hash key: %s", index.Type())
+		return fmt.Errorf("unusable as hash key: %s", index.Type().Display())
 	}
 
-	pair, ok := hashObject.Pairs[key.HashKey()]
+	pair, ok := hashObject.Get(key)
 	if !ok {
 		return vm.push(Null)
 	}
@@ -360,26 +624,56 @@ func (vm *VM) executeHashIndex(hash, index object.Object) error {
 	return vm.push(pair.Value)
 }
 
+// function for assigning to an element of an array or hash in place, the
+// assigned value is pushed back as the result of the assignment
+func (vm *VM) executeSetIndex(left, index, value object.Object) error {
+	switch left := left.(type) {
+	case *object.Array:
+		if left.Frozen {
+			return fmt.Errorf("cannot assign to frozen array")
+		}
+		i, ok := index.(*object.Integer)
+		if !ok {
+			return fmt.Errorf("array index must be integer, got %s", index.Type().Display())
+		}
+		if i.Value < 0 || i.Value >= int64(len(left.Elements)) {
+			return fmt.Errorf("index out of range: %d (length %d)", i.Value, len(left.Elements))
+		}
+		left.Set(int(i.Value), value)
+	case *object.Hash:
+		if left.Frozen {
+			return fmt.Errorf("cannot assign to frozen hash")
+		}
+		key, ok := index.(object.Hashable)
+		if !ok {
+			return fmt.Errorf("unusable as hash key: %s", index.Type().Display())
+		}
+		left.Set(key, value)
+	default:
+		return fmt.Errorf("index assignment not supported: %s", left.Type().Display())
+	}
+
+	return vm.push(value)
+}
+
 func (vm *VM) buildHash(startIndex, endIndex int) (object.Object, error) {
-	hashedPairs := make(map[object.HashKey]object.HashPair)
+	hash := &object.Hash{Pairs: make(map[object.HashKey]object.HashPair)}
 
 	// key and then value are pushed into the stack
 	for i := startIndex; i < endIndex; i += 2 {
 		key := vm.stack[i]
 		value := vm.stack[i+1]
 
-		pair := object.HashPair{Key: key, Value: value}
-
 		// check if key is hashable
 		hashKey, ok := key.(object.Hashable)
 		if !ok {
-			return nil, fmt.Errorf("unusable as hash key: %s", key.Type())
+			return nil, fmt.Errorf("unusable as hash key: %s", key.Type().Display())
 		}
 
-		hashedPairs[hashKey.HashKey()] = pair
+		hash.Set(hashKey, value)
 	}
 
-	return &object.Hash{Pairs: hashedPairs}, nil
+	return hash, nil
 }
 
 func (vm *VM) buildArray(startIndex, endIndex int) object.Object {
@@ -392,42 +686,22 @@ func (vm *VM) buildArray(startIndex, endIndex int) object.Object {
 	return &object.Array{Elements: elements}
 }
 
-// function that determines if an object.Object is truthy
-func isTruthy(obj object.Object) bool {
-	switch obj := obj.(type) {
-	case *object.Boolean:
-		return obj.Value
-	case *object.Null:
-		return false
-	default:
-		return true
-	}
-}
-
 func (vm *VM) executeBangOperator() error {
 	operand := vm.pop()
-
-	switch operand {
-	case True:
-		return vm.push(False)
-	case False:
-		return vm.push(True)
-	case Null:
-		return vm.push(True)
-	default:
-		return vm.push(False)
-	}
+	return vm.push(nativeBoolToBooleanObject(!object.IsTruthy(operand)))
 }
 
 func (vm *VM) executeMinusOperator() error {
 	operand := vm.pop()
 
-	if operand.Type() != object.INTEGER_OBJ {
-		return fmt.Errorf("unsupported type for negation: %s", operand.Type())
+	switch operand := operand.(type) {
+	case *object.Integer:
+		return vm.push(&object.Integer{Value: -operand.Value})
+	case *object.Float:
+		return vm.push(&object.Float{Value: -operand.Value})
+	default:
+		return fmt.Errorf("unsupported type for negation: %s", operand.Type().Display())
 	}
-
-	value := operand.(*object.Integer).Value
-	return vm.push(&object.Integer{Value: -value})
 }
 
 func (vm *VM) executeComparison(op code.Opcode) error {
@@ -438,6 +712,22 @@ func (vm *VM) executeComparison(op code.Opcode) error {
 		return vm.executeIntegerComparison(op, left, right)
 	}
 
+	if object.Numeric(left) && object.Numeric(right) {
+		return vm.executeFloatComparison(op, left, right)
+	}
+
+	// null only equals null, compared by type rather than by identity so
+	// it doesn't matter which instance of it an operand is
+	if left.Type() == object.NULL_OBJ || right.Type() == object.NULL_OBJ {
+		bothNull := left.Type() == right.Type()
+		switch op {
+		case code.OpEqual:
+			return vm.push(nativeBoolToBooleanObject(bothNull))
+		case code.OpNotEqual:
+			return vm.push(nativeBoolToBooleanObject(!bothNull))
+		}
+	}
+
 	switch op {
 	case code.OpEqual:
 		return vm.push(nativeBoolToBooleanObject(right == left))
@@ -445,7 +735,7 @@ func (vm *VM) executeComparison(op code.Opcode) error {
 		return vm.push(nativeBoolToBooleanObject(right != left))
 	default:
 		return fmt.Errorf("unknown operator: %d (%s %s)",
-			op, left.Type(), right.Type())
+			op, left.Type().Display(), right.Type().Display())
 	}
 }
 
@@ -460,6 +750,28 @@ func (vm *VM) executeIntegerComparison(op code.Opcode, left, right object.Object
 		return vm.push(nativeBoolToBooleanObject(rightValue != leftValue))
 	case code.OpGreaterThan:
 		return vm.push(nativeBoolToBooleanObject(leftValue > rightValue))
+	case code.OpGreaterEqual:
+		return vm.push(nativeBoolToBooleanObject(leftValue >= rightValue))
+	default:
+		return fmt.Errorf("unknown operator: %d", op)
+	}
+}
+
+// function for comparing numbers where at least one of them is a float,
+// integers are promoted to floats like in the interpreter
+func (vm *VM) executeFloatComparison(op code.Opcode, left, right object.Object) error {
+	leftValue, _ := object.ToFloat(left)
+	rightValue, _ := object.ToFloat(right)
+
+	switch op {
+	case code.OpEqual:
+		return vm.push(nativeBoolToBooleanObject(rightValue == leftValue))
+	case code.OpNotEqual:
+		return vm.push(nativeBoolToBooleanObject(rightValue != leftValue))
+	case code.OpGreaterThan:
+		return vm.push(nativeBoolToBooleanObject(leftValue > rightValue))
+	case code.OpGreaterEqual:
+		return vm.push(nativeBoolToBooleanObject(leftValue >= rightValue))
 	default:
 		return fmt.Errorf("unknown operator: %d", op)
 	}
@@ -478,15 +790,19 @@ func (vm *VM) executeBinaryOperation(op code.Opcode) error {
 	left := vm.pop()
 
 	rightType := right.Type()
-	leftType := right.Type()
+	leftType := left.Type()
 
-	if leftType == object.INTEGER_OBJ && rightType == object.INTEGER_OBJ {
-		return vm.executeBinaryIntegerOperation(op, left, right)
+	if object.Numeric(left) && object.Numeric(right) {
+		result, err := object.Arithmetic(arithmeticOperators[op], left, right)
+		if err != nil {
+			return err
+		}
+		return vm.push(result)
 	} else if leftType == object.STRING_OBJ && rightType == object.STRING_OBJ {
 		return vm.executeBinaryStringOperation(op, left, right)
 	}
 
-	return fmt.Errorf("unsupported types for binary operation: %s %s", leftType, rightType)
+	return fmt.Errorf("unsupported types for binary operation: %s %s", leftType.Display(), rightType.Display())
 }
 
 func (vm *VM) executeBinaryStringOperation(op code.Opcode, left, right object.Object) error {
@@ -500,26 +816,12 @@ func (vm *VM) executeBinaryStringOperation(op code.Opcode, left, right object.Ob
 	return vm.push(&object.String{Value: leftValue + rightValue})
 }
 
-func (vm *VM) executeBinaryIntegerOperation(op code.Opcode, left, right object.Object) error {
-	leftValue := left.(*object.Integer).Value
-	rightValue := right.(*object.Integer).Value
-
-	var result int64
-
-	switch op {
-	case code.OpAdd:
-		result = leftValue + rightValue
-	case code.OpSub:
-		result = leftValue - rightValue
-	case code.OpMul:
-		result = leftValue * rightValue
-	case code.OpDiv:
-		result = leftValue / rightValue
-	default:
-		return fmt.Errorf("unknown integer operator: %d", op)
-	}
-
-	return vm.push(&object.Integer{Value: result})
+// operators of the arithmetic opcodes as understood by object.Arithmetic
+var arithmeticOperators = map[code.Opcode]string{
+	code.OpAdd: "+",
+	code.OpSub: "-",
+	code.OpMul: "*",
+	code.OpDiv: "/",
 }
 
 func (vm *VM) push(obj object.Object) error {
@@ -540,6 +842,10 @@ func (vm *VM) pop() object.Object {
 }
 
 // function for getting the last popped elemented of the stack
+// returns nil if nothing has been popped (e.g. for an empty program)
 func (vm *VM) LastPoppedStackElement() object.Object {
+	if vm.sp >= len(vm.stack) {
+		return nil
+	}
 	return vm.stack[vm.sp]
 }
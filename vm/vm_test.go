@@ -1,11 +1,14 @@
 package vm
 
 import (
+	"errors"
 	"fmt"
 	"testing"
 
 	"github.com/stevensopilidis/monkey/ast"
+	"github.com/stevensopilidis/monkey/code"
 	"github.com/stevensopilidis/monkey/compiler"
+	"github.com/stevensopilidis/monkey/eval"
 	"github.com/stevensopilidis/monkey/lexer"
 	"github.com/stevensopilidis/monkey/object"
 	"github.com/stevensopilidis/monkey/parser"
@@ -46,6 +49,10 @@ func testExpectedObject(
 	switch expected := expected.(type) {
 	case int:
 		testIntegerObject(t, int64(expected), actual)
+	case float64:
+		result, ok := actual.(*object.Float)
+		require.True(t, ok)
+		require.Equal(t, expected, result.Value)
 	case bool:
 		testBooleanObject(t, bool(expected), actual)
 	case *object.Null:
@@ -132,6 +139,106 @@ func TestIntegerArithmetic(t *testing.T) {
 	runVmTests(t, testCases)
 }
 
+func TestFloatArithmetic(t *testing.T) {
+	testCases := []vmTestCase{
+		{"7 / 2", 3},
+		{"-7 / 2", -3},
+		{"7 / 2.0", 3.5},
+		{"7.0 / 2", 3.5},
+		{"1.5 + 1", 2.5},
+		{"1 - 0.5", 0.5},
+		{"2 * 1.25", 2.5},
+		{"1 / 0", &object.Error{Message: "division by zero: 1 / 0"}},
+	}
+
+	for _, tc := range testCases {
+		program := parse(tc.input)
+		comp := compiler.New()
+		require.NoError(t, comp.Compile(program))
+
+		vm := New(comp.Bytecode())
+		err := vm.Run()
+		if expected, ok := tc.expected.(*object.Error); ok {
+			require.NotNil(t, err)
+			require.Equal(t, expected.Message, errors.Unwrap(err).Error())
+			continue
+		}
+		require.NoError(t, err)
+		testExpectedObject(t, tc.expected, vm.LastPoppedStackElement())
+	}
+}
+
+func TestArithmeticMatchesEvaluator(t *testing.T) {
+	inputs := []string{
+		"7 / 2", "7 / 2.0", "7.5 / 2.5", "-9 / 4", "3 * 1.5", "3 * 2",
+		"0.1 + 0.2", "10 - 2.5", "1 + 2 * 3 - 4 / 2", "1.0 / 0",
+	}
+
+	for _, input := range inputs {
+		evaluated := eval.Eval(parse(input), object.NewEnvironment())
+
+		comp := compiler.New()
+		require.NoError(t, comp.Compile(parse(input)))
+		vm := New(comp.Bytecode())
+		require.NoError(t, vm.Run())
+
+		actual := vm.LastPoppedStackElement()
+		require.Equal(t, evaluated.Type(), actual.Type(), input)
+		require.Equal(t, evaluated.Inspect(), actual.Inspect(), input)
+
+		// folding the constants at compile time gives the same result
+		comp = compiler.New()
+		comp.Optimize()
+		require.NoError(t, comp.Compile(parse(input)))
+		vm = New(comp.Bytecode())
+		require.NoError(t, vm.Run())
+		require.Equal(t, actual.Inspect(), vm.LastPoppedStackElement().Inspect(), input)
+	}
+}
+
+func TestFloatNegation(t *testing.T) {
+	program := parse("-1.5")
+	comp := compiler.New()
+	err := comp.Compile(program)
+	require.NoError(t, err)
+
+	vm := New(comp.Bytecode())
+	err = vm.Run()
+	require.NoError(t, err)
+
+	float, ok := vm.LastPoppedStackElement().(*object.Float)
+	require.True(t, ok)
+	require.Equal(t, -1.5, float.Value)
+}
+
+func TestNegationOfDynamicOperand(t *testing.T) {
+	program := parse(`let x = "x"; -x`)
+	comp := compiler.New()
+	err := comp.Compile(program)
+	require.NoError(t, err)
+
+	vm := New(comp.Bytecode())
+	err = vm.Run()
+	require.NotNil(t, err)
+	require.Equal(t, "unsupported type for negation: string", errors.Unwrap(err).Error())
+}
+
+func TestNullComparisons(t *testing.T) {
+	// there is no null literal, indexing out of range produces it
+	testCases := []vmTestCase{
+		{"[][0] == [][0]", true},
+		{"[][0] != [][0]", false},
+		{"5 == [][0]", false},
+		{"[][0] != 5", true},
+		{"[][0] == false", false},
+		{`"" != [][0]`, true},
+		{"if (false) { 1 } == {}[1]", true},
+		{"let f = fn() { }; f() == [][0]", true},
+	}
+
+	runVmTests(t, testCases)
+}
+
 func TestBooleanExpressions(t *testing.T) {
 	testCases := []vmTestCase{
 		{"true", true},
@@ -141,6 +248,27 @@ func TestBooleanExpressions(t *testing.T) {
 		{"1 < 1", false},
 		{"1 > 1", false},
 		{"1 == 1", true},
+		{"1 <= 1", true},
+		{"2 >= 3", false},
+		{"1 <= 2", true},
+		{"2 <= 1", false},
+		{"3 >= 3", true},
+		{"1.5 <= 2", true},
+		{"2.5 >= 3.5", false},
+		{"1.5 > 1", true},
+		{"1 == 1.0", true},
+		{"1.5 == 1.5", true},
+		{"1.5 != 1.5", false},
+		// distinct float objects are compared by value
+		{"let a = 1.5; let b = 1.5; a == b", true},
+		{"1 < 2.0", true},
+		{"2.0 > 3", false},
+		{"2.5 > 2", true},
+		{"2.0 >= 2", true},
+		{"5 == 5.0", true},
+		{"5 != 5.0", false},
+		{"5 != 4.0", true},
+		{"5.5 == 5", false},
 		{"1 != 1", false},
 		{"1 == 2", false},
 		{"1 != 2", true},
@@ -165,6 +293,30 @@ func TestBooleanExpressions(t *testing.T) {
 	runVmTests(t, testCases)
 }
 
+func TestBinaryOperationOperandTypes(t *testing.T) {
+	// the type of each operand is read from that operand, a right operand
+	// of the same type must not let the left one through
+	testCases := []struct {
+		input         string
+		expectedError string
+	}{
+		{`"a" + 1`, "unsupported types for binary operation: string integer"},
+		{`1 + "a"`, "unsupported types for binary operation: integer string"},
+		{"true - 1", "unsupported types for binary operation: boolean integer"},
+		{"[1] * 2.5", "unsupported types for binary operation: array float"},
+	}
+
+	for _, tc := range testCases {
+		comp := compiler.New()
+		require.NoError(t, comp.Compile(parse(tc.input)))
+
+		vm := New(comp.Bytecode())
+		err := vm.Run()
+		require.NotNil(t, err, tc.input)
+		require.Equal(t, tc.expectedError, errors.Unwrap(err).Error(), tc.input)
+	}
+}
+
 func TestStringExpressions(t *testing.T) {
 	testCases := []vmTestCase{
 		{`"monkey"`, "monkey"},
@@ -221,11 +373,132 @@ func TestIndexExpressions(t *testing.T) {
 		{"{1: 1, 2: 2}[2]", 2},
 		{"{1: 1}[0]", Null},
 		{"{}[0]", Null},
+		{`"hello"[1]`, "e"},
+		{`"hello"[len("hello") - 1]`, "o"},
+		{`"héllo"[1]`, "é"},
+		{`"hello"[5]`, Null},
+		{`"hello"[-1]`, Null},
+		{`""[0]`, Null},
+	}
+
+	runVmTests(t, testCases)
+}
+
+func TestIndexExpressionErrors(t *testing.T) {
+	testCases := []struct {
+		input         string
+		expectedError string
+	}{
+		{"{}[fn(x) { x }]", "unusable as hash key: function"},
+		{`{"a": 1}[[1]]`, "unusable as hash key: array"},
+		{"let h = {1: 2}; let f = fn() { h[{}] }; f()", "unusable as hash key: hash"},
+	}
+
+	for _, tc := range testCases {
+		comp := compiler.New()
+		require.NoError(t, comp.Compile(parse(tc.input)))
+
+		vm := New(comp.Bytecode())
+		err := vm.Run()
+		require.NotNil(t, err, tc.input)
+		require.Equal(t, tc.expectedError, errors.Unwrap(err).Error(), tc.input)
+	}
+}
+
+func TestIndexAssignment(t *testing.T) {
+	testCases := []vmTestCase{
+		{"let a = [1, 2, 3]; a[0] = 9; a[0]", 9},
+		{"let a = [1, 2, 3]; a[1] = 5", 5},
+		{"let a = [1, 2, 3]; a[2] = a[0] + a[1]; a", []int{1, 2, 3}},
+		{"let a = [[1], [2]]; a[1][0] = 7; a[1][0]", 7},
+		{"let a = [1, 2]; let b = push(a, 3); a[0] = 9; b[0]", 1},
+		{"let a = [1, 2, 3]; let r = rest(a); r[0] = 9; a[1]", 2},
+		{"let f = fn() { let a = [0]; a[0] = 4; a[0] }; f()", 4},
+		{`let h = {"k": 0}; h["k"] = 1; h["k"]`, 1},
+		{`let h = {}; h["new"] = 2; h["new"]`, 2},
+		{"let h = {}; let set = fn(k, v) { h[k] = v }; set(true, 3); h[true]", 3},
 	}
 
 	runVmTests(t, testCases)
 }
 
+func TestBuiltinsMatchEvaluator(t *testing.T) {
+	inputs := []string{
+		`len("")`, `len("four")`, `len("héllo")`, "len([1, 2, 3])", "len([])",
+		"len(1)", `len("one", "two")`, "len()",
+		"first([1, 2])", "rest([1, 2, 3])", "push([1], 2)", "max(1, 2.5)",
+		"approxEq(0.1 + 0.2, 0.3, 0.0001)",
+	}
+
+	for _, input := range inputs {
+		evaluated := eval.Eval(parse(input), object.NewEnvironment())
+
+		comp := compiler.New()
+		require.NoError(t, comp.Compile(parse(input)))
+		vm := New(comp.Bytecode())
+		require.NoError(t, vm.Run())
+
+		actual := vm.LastPoppedStackElement()
+		require.Equal(t, evaluated.Type(), actual.Type(), input)
+		require.Equal(t, evaluated.Inspect(), actual.Inspect(), input)
+	}
+}
+
+func TestIndexAssignmentMatchesEvaluator(t *testing.T) {
+	inputs := []string{
+		"let a = [1, 2, 3]; a[0] = 9; a",
+		"let a = [1, 2, 3]; a[0] = 9",
+		"let a = [1]; let b = push(a, 2); b[1] = 3; [a, b]",
+		`let h = {}; h["k"] = [1]; h["k"][0] = 2; h`,
+		"let a = [1]; a[1] = 2",
+		"let h = {}; h[[1]] = 2",
+	}
+
+	for _, input := range inputs {
+		evaluated := eval.Eval(parse(input), object.NewEnvironment())
+
+		comp := compiler.New()
+		require.NoError(t, comp.Compile(parse(input)))
+		vm := New(comp.Bytecode())
+		err := vm.Run()
+
+		if evalErr, ok := evaluated.(*object.Error); ok {
+			require.NotNil(t, err, input)
+			require.Equal(t, evalErr.Message, errors.Unwrap(err).Error(), input)
+			continue
+		}
+
+		require.NoError(t, err, input)
+		require.Equal(t, evaluated.Inspect(), vm.LastPoppedStackElement().Inspect(), input)
+	}
+}
+
+func TestIndexAssignmentErrors(t *testing.T) {
+	testCases := []struct {
+		input         string
+		expectedError string
+	}{
+		{"let a = freeze([1]); a[0] = 2", "cannot assign to frozen array"},
+		{`let h = freeze({}); h["k"] = 1`, "cannot assign to frozen hash"},
+		{"let a = [1, 2, 3]; a[3] = 1", "index out of range: 3 (length 3)"},
+		{"let a = [1, 2, 3]; a[-1] = 1", "index out of range: -1 (length 3)"},
+		{`let a = [1]; a["0"] = 1`, "array index must be integer, got string"},
+		{`let h = {}; h[fn(x) { x }] = 1`, "unusable as hash key: function"},
+		{`let s = "abc"; s[0] = "x"`, "index assignment not supported: string"},
+	}
+
+	for _, tc := range testCases {
+		comp := compiler.New()
+		err := comp.Compile(parse(tc.input))
+		require.NoError(t, err)
+
+		vm := New(comp.Bytecode())
+		err = vm.Run()
+		require.NotNil(t, err)
+		require.Equal(t, tc.expectedError, errors.Unwrap(err).Error())
+	}
+}
+
 func TestFunctionsWithoutReturnValue(t *testing.T) {
 	testCases := []vmTestCase{
 		{
@@ -412,8 +685,316 @@ func TestCallingFunctionsWithWrongArguments(t *testing.T) {
 		err = vm.Run()
 		require.NotNil(t, err)
 
-		require.Equal(t, tc.expected, err.Error())
+		require.Equal(t, tc.expected, errors.Unwrap(err).Error())
+	}
+}
+
+func TestCallingNestedFunctionsWithWrongArguments(t *testing.T) {
+	testCases := []vmTestCase{
+		{
+			input:    `let g = 1; let make = fn() { fn(a) { a + g } }; make()();`,
+			expected: `wrong number of arguments: want=1, got=0`,
+		},
+		{
+			input:    `let g = 1; let make = fn() { fn() { g } }; make()(1);`,
+			expected: `wrong number of arguments: want=0, got=1`,
+		},
+		{
+			input:    `let g = 2; let call = fn(f) { f(g) }; call(fn(a, b) { a * b });`,
+			expected: `wrong number of arguments: want=2, got=1`,
+		},
+		{
+			input:    `let fns = [fn(a) { a }]; fns[0](1, 2);`,
+			expected: `wrong number of arguments: want=1, got=2`,
+		},
+	}
+
+	for _, tc := range testCases {
+		program := parse(tc.input)
+		comp := compiler.New()
+		err := comp.Compile(program)
+
+		require.NoError(t, err)
+		vm := New(comp.Bytecode())
+
+		err = vm.Run()
+		require.NotNil(t, err)
+
+		require.Equal(t, tc.expected, errors.Unwrap(err).Error())
+	}
+}
+
+func TestCallingClosuresWithWrongArguments(t *testing.T) {
+	testCases := []vmTestCase{
+		{
+			input:    `let make = fn(x) { fn() { x; } }; make(1)(1);`,
+			expected: `wrong number of arguments: want=0, got=1`,
+		},
+		{
+			input:    `let make = fn(x) { fn(a) { a + x; } }; make(1)();`,
+			expected: `wrong number of arguments: want=1, got=0`,
+		},
+		{
+			input:    `let make = fn(x, y) { fn(a, b) { a + b + x + y; } }; make(1, 2)(1);`,
+			expected: `wrong number of arguments: want=2, got=1`,
+		},
+		{
+			input:    `fn() { let x = 1; let f = fn(a) { a + x }; f() }();`,
+			expected: `wrong number of arguments: want=1, got=0`,
+		},
+	}
+
+	for _, tc := range testCases {
+		program := parse(tc.input)
+		comp := compiler.New()
+		err := comp.Compile(program)
+
+		require.NoError(t, err)
+		vm := New(comp.Bytecode())
+
+		err = vm.Run()
+		require.NotNil(t, err)
+
+		require.Equal(t, tc.expected, errors.Unwrap(err).Error())
+	}
+}
+
+func TestClosures(t *testing.T) {
+	testCases := []vmTestCase{
+		{
+			input: `
+			let newClosure = fn(a) { fn() { a; }; };
+			let closure = newClosure(99);
+			closure();
+			`,
+			expected: 99,
+		},
+		{
+			input: `
+			let newAdder = fn(a, b) { fn(c) { a + b + c }; };
+			let adder = newAdder(1, 2);
+			adder(8);
+			`,
+			expected: 11,
+		},
+		{
+			input: `
+			let newAdderOuter = fn(a, b) {
+				let c = a + b;
+				fn(d) {
+					let e = d + c;
+					fn(f) { e + f; };
+				};
+			};
+			let newAdderInner = newAdderOuter(1, 2);
+			let adder = newAdderInner(3);
+			adder(8);
+			`,
+			expected: 14,
+		},
+		{
+			// every closure keeps the values it captured
+			input: `
+			let newClosure = fn(a) { fn() { a; }; };
+			let one = newClosure(1);
+			let two = newClosure(2);
+			one() * 10 + two();
+			`,
+			expected: 12,
+		},
+		{
+			input: `
+			let wrapper = fn() {
+				let countDown = fn(x) {
+					if (x == 0) { return 0; }
+					countDown(x - 1);
+				};
+				countDown(1);
+			};
+			wrapper();
+			`,
+			expected: 0,
+		},
+		{
+			input: `
+			let wrapper = fn(step) {
+				let sum = fn(x) {
+					if (x <= 0) { return 0; }
+					x + sum(x - step);
+				};
+				sum(10);
+			};
+			wrapper(2);
+			`,
+			expected: 30,
+		},
+	}
+
+	runVmTests(t, testCases)
+}
+
+func TestEmptyProgram(t *testing.T) {
+	inputs := []string{"", "   \n\t"}
+
+	for _, input := range inputs {
+		program := parse(input)
+		comp := compiler.New()
+		err := comp.Compile(program)
+		require.NoError(t, err)
+
+		byteCode := comp.Bytecode()
+		require.Equal(t, 0, len(byteCode.Instructions))
+
+		vm := New(byteCode)
+		err = vm.Run()
+		require.NoError(t, err)
+		require.Equal(t, 0, vm.sp)
+	}
+}
+
+func TestCheckStack(t *testing.T) {
+	inputs := []string{
+		"1; 2 + 3; let a = 4; a;",
+		"if (true) { 10 }; if (false) { 10 } else { 20 };",
+		"let f = fn(a, b) { let c = a + b; c }; f(1, 2); [1, f(2, 3)];",
+		"let x = do { let a = 2; a * 3 }; [1, do { 2; 3 }];",
+		`{"a": 1}["a"]; len([1, 2]);`,
+		"true && false; false || true; let x = 1 && 0 || 2; [x, 0 && 1];",
+	}
+
+	for _, input := range inputs {
+		program := parse(input)
+		comp := compiler.New()
+		err := comp.Compile(program)
+		require.NoError(t, err)
+
+		vm := New(comp.Bytecode())
+		vm.CheckStack = true
+		err = vm.Run()
+		require.NoError(t, err)
+	}
+}
+
+func TestLogicalOperatorsLeaveStackBalanced(t *testing.T) {
+	testCases := []struct {
+		input    string
+		expected interface{}
+	}{
+		{"true && false", false},
+		{"false || 3", 3},
+		{"1 && 2 && 3 || 4", 3},
+		// leaking a value per iteration would overflow the stack
+		{`
+		let i = 0;
+		let hits = 0;
+		while (i < 3000) {
+			let hits = hits + (i > 10 && i < 20 && 1 || 0);
+			let i = i + 1;
+		}
+		hits
+		`, 9},
+	}
+
+	for _, tc := range testCases {
+		comp := compiler.New()
+		require.NoError(t, comp.Compile(parse(tc.input)))
+
+		vm := New(comp.Bytecode())
+		require.NoError(t, vm.Run())
+		require.Equal(t, 0, vm.sp)
+		testExpectedObject(t, tc.expected, vm.LastPoppedStackElement())
+	}
+}
+
+func TestCheckStackImbalance(t *testing.T) {
+	instructions := concatInstructions([]code.Instructions{
+		code.Make(code.OpConstant, 0),
+		code.Make(code.OpConstant, 0),
+		code.Make(code.OpPop),
+	})
+	byteCode := &compiler.Bytecode{
+		Instructions:  instructions,
+		Constants:     []object.Object{&object.Integer{Value: 1}},
+		StatementEnds: []int{len(instructions)},
+	}
+
+	vm := New(byteCode)
+	require.NoError(t, vm.Run())
+
+	vm = New(byteCode)
+	vm.CheckStack = true
+	err := vm.Run()
+	require.NotNil(t, err)
+	require.Equal(t, "stack imbalance after statement ending at 7: sp=1, want=0",
+		errors.Unwrap(err).Error())
+}
+
+func TestCheckStackUnderflow(t *testing.T) {
+	// the function pops once more than it pushes, reaching into the
+	// stack of main where the function itself is stored
+	fn := &object.CompiledFunction{
+		Instructions: concatInstructions([]code.Instructions{
+			code.Make(code.OpConstant, 1),
+			code.Make(code.OpPop),
+			code.Make(code.OpPop),
+			code.Make(code.OpReturn),
+		}),
+		Name: "broken",
 	}
+	instructions := concatInstructions([]code.Instructions{
+		code.Make(code.OpConstant, 0),
+		code.Make(code.OpCall, 0),
+		code.Make(code.OpPop),
+	})
+	byteCode := &compiler.Bytecode{
+		Instructions:  instructions,
+		Constants:     []object.Object{fn, &object.Integer{Value: 1}},
+		StatementEnds: []int{len(instructions)},
+	}
+
+	// without the check the corruption goes unnoticed
+	vm := New(byteCode)
+	require.NoError(t, vm.Run())
+
+	vm = New(byteCode)
+	vm.CheckStack = true
+	err := vm.Run()
+	require.NotNil(t, err)
+	require.Equal(t, "stack underflow in broken at 4: sp=0, base pointer=1",
+		errors.Unwrap(err).Error())
+	require.Equal(t, []string{"broken", "main"}, err.(*RuntimeError).Stack)
+}
+
+func concatInstructions(instructions []code.Instructions) code.Instructions {
+	out := code.Instructions{}
+
+	for _, instruction := range instructions {
+		out = append(out, instruction...)
+	}
+
+	return out
+}
+
+func TestRuntimeErrorCallStack(t *testing.T) {
+	input := `
+	let inner = fn() { 1(); };
+	let outer = fn() { inner(); };
+	outer();
+	`
+	program := parse(input)
+	comp := compiler.New()
+	err := comp.Compile(program)
+	require.NoError(t, err)
+
+	vm := New(comp.Bytecode())
+	err = vm.Run()
+	require.NotNil(t, err)
+
+	runtimeErr, ok := err.(*RuntimeError)
+	require.True(t, ok)
+	require.Equal(t, []string{"inner", "outer", "main"}, runtimeErr.Stack)
+	require.Equal(t, "calling non-function and non-built-in\n\tat inner\n\tat outer\n\tat main",
+		err.Error())
 }
 
 func TestCallingFunctionsWithoutArguments(t *testing.T) {
@@ -461,9 +1042,25 @@ func TestCallingFunctionsWithoutArguments(t *testing.T) {
 	runVmTests(t, testCases)
 }
 
+func TestDoExpressions(t *testing.T) {
+	testCases := []vmTestCase{
+		{"let x = do { let a = 2; a * 3 }; x;", 6},
+		{"do { 1; 2 }", 2},
+		{"let f = fn() { let y = do { 5 }; y + 1 }; f();", 6},
+		{"1; do { }", Null},
+		{"do { let a = 1; }", Null},
+	}
+
+	runVmTests(t, testCases)
+}
+
 func TestConditionals(t *testing.T) {
 	testCases := []vmTestCase{
 		{"if (true) { 10 }", 10},
+		{"if (true) { let x = 1; x }", 1},
+		{"if (false) { 1 } else { let y = 2; y * 2 }", 4},
+		{"let f = fn() { if (true) { let z = 3; z } }; f()", 3},
+		{"if (true) { 1; let x = 2; }", Null},
 		{"if (true) { 10 } else { 20 }", 10},
 		{"if (false) { 10 } else { 20 } ", 20},
 		{"if (1) { 10 }", 10},
@@ -472,6 +1069,9 @@ func TestConditionals(t *testing.T) {
 		{"if (1 > 2) { 10 } else { 20 }", 20},
 		{"if (1 > 2) { 10 }", Null},
 		{"if (false) {10}", Null},
+		{"let x = 2; if (x == 1) { 10 } else if (x == 2) { 20 } else { 30 }", 20},
+		{"let x = 3; if (x == 1) { 10 } else if (x == 2) { 20 } else { 30 }", 30},
+		{"let x = 3; if (x == 1) { 10 } else if (x == 2) { 20 }", Null},
 	}
 
 	runVmTests(t, testCases)
@@ -479,13 +1079,14 @@ func TestConditionals(t *testing.T) {
 
 func TestBuiltinFunctions(t *testing.T) {
 	testCases := []vmTestCase{
-		// {`len("")`, 0},
-		// {`len("four")`, 4},
-		// {`len("hello world")`, 11},
+		{`len("")`, 0},
+		{`len("four")`, 4},
+		{`len("hello world")`, 11},
+		{`len("héllo")`, 5},
 		// {
 		// 	`len(1)`,
 		// 	&object.Error{
-		// 		Message: "argument to `len` not supported, got INTEGER",
+		// 		Message: "argument to `len` not supported, got integer",
 		// 	},
 		// },
 		// {`len("one", "two")`,
@@ -500,14 +1101,14 @@ func TestBuiltinFunctions(t *testing.T) {
 		// {`first([])`, Null},
 		// {`first(1)`,
 		// 	&object.Error{
-		// 		Message: "argument to `first` must be ARRAY, got INTEGER",
+		// 		Message: "argument to `first` must be array, got integer",
 		// 	},
 		// },
 		// {`last([1, 2, 3])`, 3},
 		// {`last([])`, Null},
 		// {`last(1)`,
 		// 	&object.Error{
-		// 		Message: "argument to `last` must be ARRAY, got INTEGER",
+		// 		Message: "argument to `last` must be array, got integer",
 		// 	},
 		// },
 		// {`rest([1, 2, 3])`, []int{2, 3}},
@@ -515,7 +1116,7 @@ func TestBuiltinFunctions(t *testing.T) {
 		// {`push([], 1)`, []int{1}},
 		// {`push(1, 1)`,
 		// 	&object.Error{
-		// 		Message: "argument to `push` must be ARRAY, got INTEGER",
+		// 		Message: "argument to `push` must be array, got integer",
 		// 	},
 		// },
 	}
@@ -532,3 +1133,494 @@ func TestGlobalLetStatements(t *testing.T) {
 
 	runVmTests(t, testCases)
 }
+
+func TestLogicalOperators(t *testing.T) {
+	testCases := []vmTestCase{
+		{"true && true", true},
+		{"true && false", false},
+		{"false && true", false},
+		{"false && false", false},
+		{"true || true", true},
+		{"true || false", true},
+		{"false || true", true},
+		{"false || false", false},
+		{"1 && 2", 2},
+		{"if (false) { 1 } || 5", 5},
+		{"let f = fn(a, b) { a && b || 3 }; f(true, false)", 3},
+		// calling 1 is a runtime error so these only pass if the right
+		// operand is skipped
+		{"false && 1()", false},
+		{"true || 1()", true},
+	}
+
+	runVmTests(t, testCases)
+
+	for _, input := range []string{"true && 1()", "false || 1()"} {
+		program := parse(input)
+		comp := compiler.New()
+		err := comp.Compile(program)
+		require.NoError(t, err)
+
+		vm := New(comp.Bytecode())
+		err = vm.Run()
+		require.NotNil(t, err)
+		require.Equal(t, "calling non-function and non-built-in", errors.Unwrap(err).Error())
+	}
+}
+
+func TestConstantFolding(t *testing.T) {
+	testCases := []vmTestCase{
+		{"2 + 3", 5},
+		{"(1 + 2) * -3 - 4 / 2", -11},
+		{"let a = 4; a * (2 + 3)", 20},
+		// interned functions
+		{"let fs = [fn(x) { x * 2 }, fn(x) { x * 2 }]; fs[0](1) + fs[1](2)", 6},
+	}
+
+	for _, tc := range testCases {
+		program := parse(tc.input)
+
+		comp := compiler.New()
+		comp.Optimize()
+		err := comp.Compile(program)
+		require.NoError(t, err)
+
+		vm := New(comp.Bytecode())
+		err = vm.Run()
+		require.NoError(t, err)
+
+		testExpectedObject(t, tc.expected, vm.LastPoppedStackElement())
+	}
+}
+
+func TestRunDeserializedBytecode(t *testing.T) {
+	input := `
+	let twice = fn(f, x) { f(f(x)) };
+	let greet = fn(name) { "hello " + name };
+	[twice(fn(n) { n * 2 }, 3), greet("monkey")]
+	`
+	program := parse(input)
+	comp := compiler.New()
+	err := comp.Compile(program)
+	require.NoError(t, err)
+
+	data, err := comp.Bytecode().Serialize()
+	require.NoError(t, err)
+
+	bytecode, err := compiler.Deserialize(data)
+	require.NoError(t, err)
+
+	vm := New(bytecode)
+	err = vm.Run()
+	require.NoError(t, err)
+	require.Equal(t, `[12, hello monkey]`, vm.LastPoppedStackElement().Inspect())
+
+	// index assignment and tail calls use the opcodes added after the
+	// first serialized version
+	input = `
+	let sum = fn(n, acc) { if (n == 0) { acc } else { sum(n - 1, acc + n) } };
+	let a = [0, 0];
+	a[1] = sum(100, 0);
+	a
+	`
+	comp = compiler.New()
+	require.NoError(t, comp.Compile(parse(input)))
+	data, err = comp.Bytecode().Serialize()
+	require.NoError(t, err)
+	bytecode, err = compiler.Deserialize(data)
+	require.NoError(t, err)
+
+	vm = New(bytecode)
+	require.NoError(t, vm.Run())
+	require.Equal(t, "[0, 5050]", vm.LastPoppedStackElement().Inspect())
+}
+
+func TestTailCalls(t *testing.T) {
+	testCases := []vmTestCase{
+		{
+			input: `
+			let countDown = fn(x) { if (x == 0) { return 0; } countDown(x - 1) };
+			countDown(100000);
+			`,
+			expected: 0,
+		},
+		{
+			input: `
+			let sum = fn(n, acc) { if (n == 0) { acc } else { sum(n - 1, acc + n) } };
+			sum(100000, 0);
+			`,
+			expected: 5000050000,
+		},
+		{
+			input: `
+			let fib = fn(n) { if (n < 2) { n } else { fib(n - 1) + fib(n - 2) } };
+			fib(15);
+			`,
+			expected: 610,
+		},
+	}
+
+	runVmTests(t, testCases)
+}
+
+func TestFrameOverflow(t *testing.T) {
+	// the recursive call isn't in tail position and every frame only takes
+	// up the stack slot of the callee so frames run out before the stack
+	input := `
+	let f = fn() { f(); 1 };
+	f();
+	`
+	program := parse(input)
+	comp := compiler.New()
+	err := comp.Compile(program)
+	require.NoError(t, err)
+
+	vm := New(comp.Bytecode())
+	err = vm.Run()
+	require.NotNil(t, err)
+	require.Equal(t, "frame overflow", errors.Unwrap(err).Error())
+}
+
+func TestArityBuiltin(t *testing.T) {
+	testCases := []vmTestCase{
+		{"arity(fn(a, b) {})", 2},
+		{"let f = fn(x) { x }; arity(f)", 1},
+		{"let make = fn(a) { fn(b, c) { a } }; arity(make(1))", 2},
+	}
+
+	runVmTests(t, testCases)
+}
+
+func TestFormatBuiltin(t *testing.T) {
+	testCases := []vmTestCase{
+		{`format("{} + {} = {}", 1, 2, 3)`, "1 + 2 = 3"},
+		// the result is a string the vm can keep working with
+		{`format("%d", 4) + "!"`, "4!"},
+		{`format("{}", 1, 2)`, &object.Error{Message: "wrong number of values for `format`: placeholders=1, got=2"}},
+	}
+
+	runVmTests(t, testCases)
+}
+
+func TestLambdas(t *testing.T) {
+	testCases := []vmTestCase{
+		{`let add = \(x, y) -> x + y; add(2, 3)`, 5},
+		{`(\() -> 7)()`, 7},
+		{`let count = \(n) -> if (n == 0) { 0 } else { count(n - 1) }; count(10000)`, 0},
+	}
+
+	runVmTests(t, testCases)
+}
+
+func TestSeedGlobals(t *testing.T) {
+	symbolTable := compiler.NewSymbolTable()
+	x := symbolTable.Define("x")
+
+	comp := compiler.NewWithState(symbolTable, []object.Object{})
+	err := comp.Compile(parse("let y = x * 2;"))
+	require.NoError(t, err)
+
+	y, ok := symbolTable.Resolve("y")
+	require.True(t, ok)
+
+	vm := New(comp.Bytecode())
+	err = vm.SetGlobal(x.Index, &object.Integer{Value: 21})
+	require.NoError(t, err)
+
+	err = vm.Run()
+	require.NoError(t, err)
+	testIntegerObject(t, 42, vm.Globals()[y.Index])
+
+	require.EqualError(t, vm.SetGlobal(-1, Null), "global index out of range: -1")
+	require.EqualError(t, vm.SetGlobal(GlobalsSize, Null),
+		fmt.Sprintf("global index out of range: %d", GlobalsSize))
+}
+
+func TestHostInjectedGlobals(t *testing.T) {
+	comp := compiler.New()
+	answer := comp.SymbolTable().Define("answer")
+	greeting := comp.SymbolTable().Define("greeting")
+
+	err := comp.Compile(parse(`[answer + 1, greeting + " monkey"]`))
+	require.NoError(t, err)
+
+	vm := New(comp.Bytecode())
+	require.NoError(t, vm.SetGlobal(answer.Index, &object.Integer{Value: 41}))
+	require.NoError(t, vm.SetGlobal(greeting.Index, &object.String{Value: "hello"}))
+
+	err = vm.Run()
+	require.NoError(t, err)
+	require.Equal(t, "[42, hello monkey]", vm.LastPoppedStackElement().Inspect())
+}
+
+func TestCallArity(t *testing.T) {
+	// builtins report a wrong number of arguments as an error value
+	testCases := []vmTestCase{
+		{`len("one", "two")`, &object.Error{Message: "wrong number of arguments. got=2, want=1"}},
+		{`len()`, &object.Error{Message: "wrong number of arguments. got=0, want=1"}},
+		{`push([1])`, &object.Error{Message: "wrong number of arguments. got=1, want=2"}},
+		{`push([], 1, 2)`, &object.Error{Message: "wrong number of arguments. got=3, want=2"}},
+	}
+
+	runVmTests(t, testCases)
+
+	// compiled functions fail before a frame is pushed for them, so the
+	// error is raised in the frame of the caller
+	errorCases := []struct {
+		input         string
+		expectedError string
+		expectedStack []string
+	}{
+		{`let f = fn(a) { a }; f(1, 2);`, "wrong number of arguments: want=1, got=2", []string{"main"}},
+		{`let f = fn(a, b) { a }; f();`, "wrong number of arguments: want=2, got=0", []string{"main"}},
+		{
+			`let f = fn(a) { if (a) { f() } else { 1 } }; f(true)`,
+			"wrong number of arguments: want=1, got=0",
+			[]string{"f", "main"},
+		},
+	}
+
+	for _, tc := range errorCases {
+		program := parse(tc.input)
+		comp := compiler.New()
+		err := comp.Compile(program)
+		require.NoError(t, err)
+
+		vm := New(comp.Bytecode())
+		err = vm.Run()
+		require.NotNil(t, err)
+		require.Equal(t, tc.expectedError, errors.Unwrap(err).Error())
+		require.Equal(t, tc.expectedStack, err.(*RuntimeError).Stack)
+	}
+}
+
+func TestWhileLoops(t *testing.T) {
+	testCases := []vmTestCase{
+		{"let i = 0; while (i < 5) { let i = i + 1; }; i", 5},
+		{"let i = 0; while (true) { if (i == 5) { break; } let i = i + 1; }; i", 5},
+		{
+			// sum of 1 to 5 without 3
+			`
+			let i = 0; let sum = 0;
+			while (i < 5) {
+				let i = i + 1;
+				if (i == 3) { continue; }
+				let sum = sum + i;
+			};
+			sum
+			`,
+			12,
+		},
+		{
+			`
+			let count = fn(n) {
+				let i = 0; let steps = 0;
+				while (true) {
+					if (i == n) { break; }
+					let j = 0;
+					while (j < n) { let j = j + 1; let steps = steps + 1; }
+					let i = i + 1;
+				}
+				steps
+			};
+			count(4)
+			`,
+			16,
+		},
+		{"let f = fn() { while (true) { return 7; } }; f()", 7},
+		{"if (true) { while (false) {} }", Null},
+		{"if (true) { let a = 1; }", Null},
+	}
+
+	runVmTests(t, testCases)
+}
+
+func TestUnassignedGlobals(t *testing.T) {
+	testCases := []struct {
+		input         string
+		expectedError string
+	}{
+		{"if (false) { let a = 1; }; a", "use of unassigned global"},
+		{"while (false) { let b = 1; }; b", "use of unassigned global"},
+		{"if (true) { let c = 1; }; c", ""},
+	}
+
+	for _, tc := range testCases {
+		comp := compiler.New()
+		err := comp.Compile(parse(tc.input))
+		require.NoError(t, err)
+
+		vm := New(comp.Bytecode())
+		err = vm.Run()
+		if tc.expectedError == "" {
+			require.NoError(t, err)
+		} else {
+			require.NotNil(t, err)
+			require.Equal(t, tc.expectedError, errors.Unwrap(err).Error())
+		}
+	}
+}
+
+func TestMutualRecursionAcrossGlobals(t *testing.T) {
+	parity := `
+	let isEven = fn(n) { if (n == 0) { true } else { isOdd(n - 1) } };
+	let isOdd = fn(n) { if (n == 0) { false } else { isEven(n - 1) } };
+	`
+	testCases := []vmTestCase{
+		{parity + "isEven(10)", true},
+		{parity + "isOdd(7)", true},
+		{parity + "isEven(3)", false},
+		{parity + "isOdd(0)", false},
+		// a function may refer to one defined later as long as it isn't
+		// called before that definition has run
+		{`
+		let main = fn() { helper(2) * 10 };
+		let helper = fn(x) { x + 1 };
+		main()
+		`, 30},
+		{`
+		let a = fn() { b() + 1 };
+		let unrelated = 5;
+		let b = fn() { unrelated };
+		a()
+		`, 6},
+	}
+
+	runVmTests(t, testCases)
+}
+
+func TestForwardReferences(t *testing.T) {
+	// only functions bound by top-level lets can be referenced before
+	// them, and calling one before its let has run fails at runtime
+	testCases := []struct {
+		input        string
+		compileError string
+		runtimeError string
+	}{
+		{"let a = fn() { b() }; let r = a(); let b = fn() { 1 };", "", "use of unassigned global"},
+		{"f(); let f = fn() { 1 };", "", "use of unassigned global"},
+		{"let a = fn() { x }; let x = 1;", "undefined variable x on line 1", ""},
+		{"let f = fn() { let g = fn() { h() }; let h = fn() { 1 }; g() }; f()", "undefined variable h on line 1", ""},
+	}
+
+	for _, tc := range testCases {
+		comp := compiler.New()
+		err := comp.Compile(parse(tc.input))
+		if tc.compileError != "" {
+			require.EqualError(t, err, tc.compileError)
+			continue
+		}
+		require.NoError(t, err)
+
+		vm := New(comp.Bytecode())
+		err = vm.Run()
+		require.NotNil(t, err)
+		require.Equal(t, tc.runtimeError, errors.Unwrap(err).Error())
+	}
+}
+
+func TestGlobalsAcrossReplLines(t *testing.T) {
+	// every line is compiled and run on its own while the symbol table,
+	// constants and globals are carried over like the repl does
+	symbolTable := compiler.NewSymbolTable()
+	for i, v := range object.Builtins {
+		symbolTable.DefineBuiltin(i, v.Name)
+	}
+	constants := []object.Object{}
+	globals := make([]object.Object, GlobalsSize)
+
+	run := func(input string) (object.Object, error) {
+		comp := compiler.NewWithState(symbolTable, constants)
+		err := comp.Compile(parse(input))
+		require.NoError(t, err)
+
+		bytecode := comp.Bytecode()
+		constants = bytecode.Constants
+		vm := NewWithGlobalsStore(bytecode, globals)
+		err = vm.Run()
+		return vm.LastPoppedStackElement(), err
+	}
+
+	// x is defined while compiling but the line fails before assigning it
+	_, err := run("let x = 1 + true;")
+	require.NotNil(t, err)
+
+	_, err = run("x")
+	require.NotNil(t, err)
+	require.Equal(t, "use of unassigned global", errors.Unwrap(err).Error())
+
+	_, err = run("let x = 2; let y = 3;")
+	require.NoError(t, err)
+	x, _ := symbolTable.Resolve("x")
+	y, _ := symbolTable.Resolve("y")
+
+	// redefining gets a new index and leaves the values of other globals
+	// (and of the previous x) alone
+	_, err = run("let x = x * 10;")
+	require.NoError(t, err)
+	redefined, _ := symbolTable.Resolve("x")
+	require.NotEqual(t, x.Index, redefined.Index)
+	testIntegerObject(t, 2, globals[x.Index])
+
+	result, err := run("x + y")
+	require.NoError(t, err)
+	testIntegerObject(t, 23, result)
+	testIntegerObject(t, 3, globals[y.Index])
+}
+
+func TestUnhashableKeyLeavesStackBalanced(t *testing.T) {
+	symbolTable := compiler.NewSymbolTable()
+	constants := []object.Object{}
+	globals := make([]object.Object, GlobalsSize)
+
+	run := func(input string) (*VM, error) {
+		comp := compiler.NewWithState(symbolTable, constants)
+		err := comp.Compile(parse(input))
+		require.NoError(t, err)
+
+		bytecode := comp.Bytecode()
+		constants = bytecode.Constants
+		vm := NewWithGlobalsStore(bytecode, globals)
+		return vm, vm.Run()
+	}
+
+	vm, err := run("let a = 1; {fn(x) { x }: 1}")
+	require.NotNil(t, err)
+	require.Equal(t, "unusable as hash key: function", errors.Unwrap(err).Error())
+	require.Equal(t, 0, vm.sp)
+
+	vm, err = run(`{"b": 2, [1]: 3}`)
+	require.NotNil(t, err)
+	require.Equal(t, "unusable as hash key: array", errors.Unwrap(err).Error())
+	require.Equal(t, 0, vm.sp)
+
+	// later runs sharing the globals aren't affected
+	vm, err = run(`{"a": a, 2: [a]}[2]`)
+	require.NoError(t, err)
+	require.Equal(t, `[1]`, vm.LastPoppedStackElement().Inspect())
+}
+
+func TestReturnFromMainFrame(t *testing.T) {
+	testCases := []vmTestCase{
+		{"return 5; 6", 5},
+		{"1; return 2 * 3; 4", 6},
+		{"if (true) { return 1; } 2", 1},
+		{"let x = 0; while (true) { let x = x + 1; if (x == 3) { return x; } }", 3},
+		{"let f = fn() { return 7; }; return f(); 8", 7},
+		{"let divmod = fn(a, b) { return a / b, a - a / b * b; }; divmod(17, 5)", []int{3, 2}},
+	}
+
+	runVmTests(t, testCases)
+
+	// a bare return at the end of the main instructions leaves null behind
+	vm := New(&compiler.Bytecode{
+		Instructions: concatInstructions([]code.Instructions{
+			code.Make(code.OpConstant, 0),
+			code.Make(code.OpReturn),
+		}),
+		Constants: []object.Object{&object.Integer{Value: 1}},
+	})
+	require.NoError(t, vm.Run())
+	require.Equal(t, Null, vm.LastPoppedStackElement())
+	require.Equal(t, 1, vm.framesIndex)
+}